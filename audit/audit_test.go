@@ -0,0 +1,240 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+var errMarker = errors.New("any error")
+
+// mockSink records events, optionally failing every write.
+type mockSink struct {
+	events []*Event
+	err    error
+}
+
+func (m *mockSink) Write(e *Event) error {
+	m.events = append(m.events, e)
+	return m.err
+}
+
+// summary returns the fields of each recorded event that don't change between
+// runs.
+func (m *mockSink) summary() []Event {
+	out := []Event{}
+	for _, e := range m.events {
+		out = append(out, Event{
+			Actor:     e.Actor,
+			Op:        e.Op,
+			Name:      e.Name,
+			Encryptor: e.Encryptor,
+			Success:   e.Success,
+			Error:     e.Error,
+		})
+	}
+
+	return out
+}
+
+func TestStore(t *testing.T) {
+	data := &encryptor.EncryptedData{Ciphertext: []byte("a 🐐"), Type: encryptor.AESGCM}
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		sinkErr error
+		fn      func(s *Store) error
+		// Expected results.
+		want    []Event
+		wantErr error
+	}{
+		{
+			"Put",
+			nil,
+			func(s *Store) error { return s.Put("new", data) },
+			[]Event{
+				{Actor: "dom", Op: OpPut, Name: "new", Encryptor: "aes-gcm", Success: true},
+			},
+			nil,
+		},
+		{
+			"Put exists",
+			nil,
+			func(s *Store) error { return s.Put("secret", data) },
+			[]Event{
+				{Actor: "dom", Op: OpPut, Name: "secret", Encryptor: "aes-gcm", Error: store.ErrAlreadyExists.Error()},
+			},
+			store.ErrAlreadyExists,
+		},
+		{
+			"Get",
+			nil,
+			func(s *Store) error {
+				_, err := s.Get("secret")
+				return err
+			},
+			[]Event{
+				{Actor: "dom", Op: OpGet, Name: "secret", Encryptor: "aes-gcm", Success: true},
+			},
+			nil,
+		},
+		{
+			"Get not found",
+			nil,
+			func(s *Store) error {
+				_, err := s.Get("missing")
+				return err
+			},
+			[]Event{
+				{Actor: "dom", Op: OpGet, Name: "missing", Error: store.ErrNotFound.Error()},
+			},
+			store.ErrNotFound,
+		},
+		{
+			"Get sink error",
+			errMarker,
+			func(s *Store) error {
+				got, err := s.Get("secret")
+				if got != nil {
+					return errors.New("secret returned without audit event")
+				}
+				return err
+			},
+			[]Event{
+				{Actor: "dom", Op: OpGet, Name: "secret", Encryptor: "aes-gcm", Success: true},
+			},
+			errMarker,
+		},
+		{
+			"Get sink error with store error",
+			errMarker,
+			func(s *Store) error {
+				_, err := s.Get("missing")
+				return err
+			},
+			[]Event{
+				{Actor: "dom", Op: OpGet, Name: "missing", Error: store.ErrNotFound.Error()},
+			},
+			store.ErrNotFound,
+		},
+		{
+			"GetMany",
+			nil,
+			func(s *Store) error {
+				_, err := s.GetMany([]string{"secret", "missing"})
+				return err
+			},
+			[]Event{
+				{Actor: "dom", Op: OpGet, Name: "secret", Encryptor: "aes-gcm", Success: true},
+				{Actor: "dom", Op: OpGet, Name: "missing", Error: store.ErrNotFound.Error()},
+			},
+			nil,
+		},
+		{
+			"Delete",
+			nil,
+			func(s *Store) error { return s.Delete("secret") },
+			[]Event{
+				{Actor: "dom", Op: OpDelete, Name: "secret", Success: true},
+			},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		mem := store.NewMemory()
+		mem.Put("secret", data)
+
+		sink := &mockSink{err: tt.sinkErr}
+		s := NewStore(mem, sink, "dom")
+
+		if err := tt.fn(s); err != tt.wantErr {
+			t.Errorf("%q. error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if got := sink.summary(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q. events = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestStoreGetManyUnsupported ensures GetMany only fetches in bulk when the
+// underlying store can, leaving the caller to fetch (and audit) each secret.
+func TestStoreGetManyUnsupported(t *testing.T) {
+	sink := &mockSink{}
+	s := NewStore(struct{ store.Interface }{store.NewMemory()}, sink, "dom")
+
+	if _, err := s.GetMany([]string{"secret"}); err != store.ErrBulkUnsupported {
+		t.Errorf("Store.GetMany() error = %v, want %v", err, store.ErrBulkUnsupported)
+	}
+
+	if got := sink.summary(); len(got) != 0 {
+		t.Errorf("Store.GetMany() events = %+v, want none", got)
+	}
+}
+
+func TestEncryptor(t *testing.T) {
+	sink := &mockSink{}
+	e := NewEncryptor(encryptor.NopEncryptor{}, sink, "dom")
+
+	data, err := e.Encrypt([]byte("super secret"))
+	if err != nil {
+		t.Fatalf("Encryptor.Encrypt() error = %v", err)
+	}
+
+	if _, err := e.Decrypt(data); err != nil {
+		t.Fatalf("Encryptor.Decrypt() error = %v", err)
+	}
+
+	want := []Event{
+		{Actor: "dom", Op: OpEncrypt, Encryptor: "nop", Success: true},
+		{Actor: "dom", Op: OpDecrypt, Encryptor: "nop", Success: true},
+	}
+
+	if got := sink.summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v, want %+v", got, want)
+	}
+}
+
+// TestWriterSink ensures events are written as JSON lines, and never contain
+// the secret.
+func TestWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewWriterSink(buf)
+
+	mem := store.NewMemory()
+	s := NewStore(mem, sink, "dom")
+	e := NewEncryptor(encryptor.NopEncryptor{}, sink, "dom")
+
+	data, _ := e.Encrypt([]byte("super secret"))
+	s.Put("name", data)
+	s.Get("name")
+
+	if strings.Contains(buf.String(), "super secret") {
+		t.Errorf("audit log contains the secret: %s", buf.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+
+	for _, line := range lines {
+		ev := &Event{}
+		if err := json.Unmarshal([]byte(line), ev); err != nil {
+			t.Errorf("invalid JSON line %q: %v", line, err)
+		}
+
+		if ev.Time.IsZero() {
+			t.Errorf("event missing time: %q", line)
+		}
+	}
+}
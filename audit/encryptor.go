@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// Encryptor wraps an encryptor, recording an Event for every secret encrypted
+// or decrypted.
+//
+// Encryptors don't know the name of the secret, so events only record the
+// encryptor type and outcome - use Store to record the name.
+type Encryptor struct {
+	Encryptor encryptor.EncryptDecryptor
+	Sink      Sink
+	Actor     string
+}
+
+// NewEncryptor returns an initialised Encryptor wrapping e, recording events
+// against actor.
+func NewEncryptor(e encryptor.EncryptDecryptor, sink Sink, actor string) *Encryptor {
+	return &Encryptor{
+		Encryptor: e,
+		Sink:      sink,
+		Actor:     actor,
+	}
+}
+
+// Encrypt encrypts secret using the underlying encryptor.
+func (e *Encryptor) Encrypt(secret []byte) (*encryptor.EncryptedData, error) {
+	data, err := e.Encryptor.Encrypt(secret)
+	if err := e.record(OpEncrypt, data, err); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// EncryptContext encrypts secret using the underlying encryptor, returning
+// early if ctx is cancelled.
+func (e *Encryptor) EncryptContext(ctx context.Context, secret []byte) (*encryptor.EncryptedData, error) {
+	data, err := encryptor.WithContext(e.Encryptor).EncryptContext(ctx, secret)
+	if err := e.record(OpEncrypt, data, err); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Decrypt decrypts data using the underlying encryptor.
+func (e *Encryptor) Decrypt(data *encryptor.EncryptedData) ([]byte, error) {
+	plain, err := e.Encryptor.Decrypt(data)
+	if err := e.record(OpDecrypt, data, err); err != nil {
+		return []byte{}, err
+	}
+
+	return plain, nil
+}
+
+// DecryptContext decrypts data using the underlying encryptor, returning early
+// if ctx is cancelled.
+func (e *Encryptor) DecryptContext(ctx context.Context, data *encryptor.EncryptedData) ([]byte, error) {
+	plain, err := encryptor.WithContext(e.Encryptor).DecryptContext(ctx, data)
+	if err := e.record(OpDecrypt, data, err); err != nil {
+		return []byte{}, err
+	}
+
+	return plain, nil
+}
+
+// record writes an Event for the operation to Sink.
+func (e *Encryptor) record(op string, data *encryptor.EncryptedData, err error) error {
+	return record(e.Sink, newEvent(e.Actor, op, "", data, err), err)
+}
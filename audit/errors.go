@@ -0,0 +1,7 @@
+package audit
+
+import "errors"
+
+// ErrSyslogUnsupported is returned by NewSyslogSink on platforms without
+// syslog.
+var ErrSyslogUnsupported = errors.New("audit: syslog is not supported on this platform")
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// Operations recorded in an Event.
+const (
+	OpPut      = "put"
+	OpUpdate   = "update"
+	OpGet      = "get"
+	OpDelete   = "delete"
+	OpList     = "list"
+	OpWatch    = "watch"
+	OpHistory  = "history"
+	OpPrune    = "prune"
+	OpDescribe = "describe"
+	OpAnnotate = "annotate"
+	OpEncrypt  = "encrypt"
+	OpDecrypt  = "decrypt"
+)
+
+// Event describes a single operation on a secret.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor,omitempty"`
+	Op        string    `json:"op"`
+	Name      string    `json:"name,omitempty"`
+	Version   int       `json:"version,omitempty"`
+	Encryptor string    `json:"encryptor,omitempty"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Sink records audit events.
+//
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	Write(e *Event) error
+}
+
+// typeNames maps encryptor types to the name recorded in an Event.
+var typeNames = map[uint8]string{
+	encryptor.Nop:          "nop",
+	encryptor.AESCTR:       "aes",
+	encryptor.KMSWrapped:   "kms",
+	encryptor.Pbkdf2:       "pbkdf2",
+	encryptor.AESGCM:       "aes-gcm",
+	encryptor.KMSDirect:    "kms-direct",
+	encryptor.AESGCMCommit: "aes-gcm-commit",
+	encryptor.Cascaded:     "cascade",
+}
+
+// newEvent returns an Event for op, recording the outcome from err.
+func newEvent(actor, op, name string, data *encryptor.EncryptedData, err error) *Event {
+	e := &Event{
+		Time:    time.Now().UTC(),
+		Actor:   actor,
+		Op:      op,
+		Name:    name,
+		Success: err == nil,
+	}
+
+	if data != nil {
+		e.Encryptor = typeNames[data.Type]
+	}
+
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	return e
+}
@@ -0,0 +1,6 @@
+// Package audit records who accessed which secrets, and when.
+//
+// Stores and encryptors are wrapped so every operation emits an Event to a
+// Sink - a JSON lines file, syslog, or any io.Writer. Events never contain
+// the secret itself, either encrypted or in plain-text.
+package audit
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterSink writes each event to an io.Writer as a single line of JSON.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns an initialised WriterSink writing to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write encodes e as JSON, writing it to the underlying io.Writer followed by a
+// new line.
+func (s *WriterSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write the event in a single call so concurrent writers to the same file
+	// don't interleave lines
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// FileSink appends events to a file as JSON lines.
+type FileSink struct {
+	*WriterSink
+	f *os.File
+}
+
+// NewFileSink opens (or creates) the file at path for appending, returning an
+// initialised FileSink.
+//
+// The file is created with 0600 permissions.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{
+		WriterSink: NewWriterSink(f),
+		f:          f,
+	}, nil
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
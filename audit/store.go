@@ -0,0 +1,326 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+// Store wraps a store, recording an Event for every secret stored, fetched or
+// deleted.
+//
+// If the event cannot be written to Sink, the error is returned even though
+// the operation itself succeeded - a secret is never returned without a record
+// of it being accessed.
+type Store struct {
+	Store store.Interface
+	Sink  Sink
+	Actor string
+}
+
+// NewStore returns an initialised Store wrapping s, recording events against
+// actor.
+func NewStore(s store.Interface, sink Sink, actor string) *Store {
+	return &Store{
+		Store: s,
+		Sink:  sink,
+		Actor: actor,
+	}
+}
+
+// Put stores data in the underlying store.
+func (s *Store) Put(name string, data *encryptor.EncryptedData) error {
+	err := s.Store.Put(name, data)
+	return s.record(OpPut, name, data, err)
+}
+
+// PutWithTTL stores data in the underlying store, expiring it after ttl.
+func (s *Store) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	e, ok := s.Store.(store.Expirer)
+	if !ok {
+		return store.ErrExpiryUnsupported
+	}
+
+	err := e.PutWithTTL(name, data, ttl)
+	return s.record(OpPut, name, data, err)
+}
+
+// PutMetadata stores m as the metadata of name in the underlying store.
+func (s *Store) PutMetadata(name string, m *store.Metadata) error {
+	d, ok := s.Store.(store.Describer)
+	if !ok {
+		return store.ErrMetadataUnsupported
+	}
+
+	err := d.PutMetadata(name, m)
+	return s.record(OpAnnotate, name, nil, err)
+}
+
+// GetMetadata fetches the metadata of name from the underlying store.
+func (s *Store) GetMetadata(name string) (*store.Metadata, error) {
+	d, ok := s.Store.(store.Describer)
+	if !ok {
+		return nil, store.ErrMetadataUnsupported
+	}
+
+	m, err := d.GetMetadata(name)
+	if err := s.record(OpDescribe, name, nil, err); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// PutContext stores data in the underlying store, returning early if ctx is
+// cancelled.
+func (s *Store) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	err := store.WithContext(s.Store).PutContext(ctx, name, data)
+	return s.record(OpPut, name, data, err)
+}
+
+// Get fetches the secret from the underlying store.
+func (s *Store) Get(name string) (*encryptor.EncryptedData, error) {
+	data, err := s.Store.Get(name)
+	if err := s.record(OpGet, name, data, err); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetContext fetches the secret from the underlying store, returning early if
+// ctx is cancelled.
+func (s *Store) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	data, err := store.WithContext(s.Store).GetContext(ctx, name)
+	if err := s.record(OpGet, name, data, err); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetMany fetches the named secrets from the underlying store in a single
+// operation, recording an Event for each name. If the underlying store doesn't
+// implement store.BulkGetter, store.ErrBulkUnsupported is returned.
+func (s *Store) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	bulk, ok := s.Store.(store.BulkGetter)
+	if !ok {
+		return nil, store.ErrBulkUnsupported
+	}
+
+	res, err := bulk.GetMany(names)
+	if err != nil {
+		for _, name := range names {
+			s.record(OpGet, name, nil, err)
+		}
+
+		return nil, err
+	}
+
+	for _, name := range names {
+		d, ok := res[name]
+		if !ok {
+			s.record(OpGet, name, nil, store.ErrNotFound)
+			continue
+		}
+
+		if err := s.record(OpGet, name, d, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// List lists the names in the underlying store, recording the prefix as the
+// Event name.
+//
+// If the underlying store doesn't implement store.Lister,
+// store.ErrListUnsupported is returned.
+func (s *Store) List(opts *store.ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &store.ListOpts{}
+	}
+
+	l, ok := s.Store.(store.Lister)
+	if !ok {
+		return nil, "", store.ErrListUnsupported
+	}
+
+	names, cursor, err := l.List(opts)
+	if err := s.record(OpList, opts.Prefix, nil, err); err != nil {
+		return nil, "", err
+	}
+
+	return names, cursor, nil
+}
+
+// Watch watches the underlying store, recording the prefix as the Event name
+// when the watch starts. The individual changes are not recorded.
+//
+// If the underlying store doesn't implement store.Watcher,
+// store.ErrWatchUnsupported is returned.
+func (s *Store) Watch(ctx context.Context, prefix string) (<-chan store.WatchEvent, error) {
+	w, ok := s.Store.(store.Watcher)
+	if !ok {
+		return nil, store.ErrWatchUnsupported
+	}
+
+	events, err := w.Watch(ctx, prefix)
+	if err := s.record(OpWatch, prefix, nil, err); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// Delete removes the secret from the underlying store.
+func (s *Store) Delete(name string) error {
+	err := s.Store.Delete(name)
+	return s.record(OpDelete, name, nil, err)
+}
+
+// DeleteContext removes the secret from the underlying store, returning early
+// if ctx is cancelled.
+func (s *Store) DeleteContext(ctx context.Context, name string) error {
+	err := store.WithContext(s.Store).DeleteContext(ctx, name)
+	return s.record(OpDelete, name, nil, err)
+}
+
+// PutVersion stores data as the next version of name in the underlying store.
+func (s *Store) PutVersion(name string, data *encryptor.EncryptedData) (int, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return 0, store.ErrVersioningUnsupported
+	}
+
+	version, err := v.PutVersion(name, data)
+	e := newEvent(s.Actor, OpPut, name, data, err)
+	e.Version = version
+
+	if err := record(s.Sink, e, err); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// GetVersion fetches the given version of name from the underlying store.
+func (s *Store) GetVersion(name string, version int) (*encryptor.EncryptedData, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return nil, store.ErrVersioningUnsupported
+	}
+
+	data, err := v.GetVersion(name, version)
+	e := newEvent(s.Actor, OpGet, name, data, err)
+	e.Version = version
+
+	if err := record(s.Sink, e, err); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Versions returns the versions of name held in the underlying store.
+func (s *Store) Versions(name string) ([]int, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return nil, store.ErrVersioningUnsupported
+	}
+
+	versions, err := v.Versions(name)
+	if err := s.record(OpHistory, name, nil, err); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// Prune removes all but the keep most recent versions of name from the
+// underlying store.
+func (s *Store) Prune(name string, keep int) (int, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return 0, store.ErrVersioningUnsupported
+	}
+
+	removed, err := v.Prune(name, keep)
+	if err := s.record(OpPrune, name, nil, err); err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+// CompareAndSwapVersion stores data as the next version of name in the
+// underlying store, if the latest version is version.
+func (s *Store) CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error) {
+	v, ok := s.Store.(store.VersionSwapper)
+	if !ok {
+		return 0, store.ErrVersioningUnsupported
+	}
+
+	newVersion, err := v.CompareAndSwapVersion(name, version, data)
+	e := newEvent(s.Actor, OpUpdate, name, data, err)
+	e.Version = newVersion
+
+	if err := record(s.Sink, e, err); err != nil {
+		return 0, err
+	}
+
+	return newVersion, nil
+}
+
+// Update replaces the secret in the underlying store.
+func (s *Store) Update(name string, data *encryptor.EncryptedData) error {
+	u, ok := s.Store.(store.Updater)
+	if !ok {
+		return store.ErrUpdateUnsupported
+	}
+
+	err := u.Update(name, data)
+	return s.record(OpUpdate, name, data, err)
+}
+
+// Upsert stores data in the underlying store, replacing any existing secret.
+func (s *Store) Upsert(name string, data *encryptor.EncryptedData) error {
+	u, ok := s.Store.(store.Updater)
+	if !ok {
+		return store.ErrUpdateUnsupported
+	}
+
+	err := u.Upsert(name, data)
+	return s.record(OpUpdate, name, data, err)
+}
+
+// CompareAndSwap replaces the secret in the underlying store if its
+// cipher-text matches old.
+func (s *Store) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	u, ok := s.Store.(store.Updater)
+	if !ok {
+		return store.ErrUpdateUnsupported
+	}
+
+	err := u.CompareAndSwap(name, old, data)
+	return s.record(OpUpdate, name, data, err)
+}
+
+// record writes an Event for the operation to Sink, returning err, or the
+// error writing the event if the operation was successful.
+func (s *Store) record(op, name string, data *encryptor.EncryptedData, err error) error {
+	return record(s.Sink, newEvent(s.Actor, op, name, data, err), err)
+}
+
+// record writes e to sink, returning err, or the error writing e if err is
+// nil.
+func record(sink Sink, e *Event, err error) error {
+	if werr := sink.Write(e); werr != nil && err == nil {
+		return werr
+	}
+
+	return err
+}
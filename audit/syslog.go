@@ -0,0 +1,45 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSink sends each event to the local syslog daemon as JSON, using the
+// auth facility.
+type SyslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink returns an initialised SyslogSink, with each message tagged
+// with tag.
+func NewSyslogSink(tag string) (*SyslogSink, error) {
+	w, err := syslog.New(syslog.LOG_AUTH|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SyslogSink{w: w}, nil
+}
+
+// Write sends e to syslog, at warning level if the operation failed.
+func (s *SyslogSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if !e.Success {
+		return s.w.Warning(string(b))
+	}
+
+	return s.w.Info(string(b))
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,22 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package audit
+
+// SyslogSink is not available on this platform.
+type SyslogSink struct{}
+
+// NewSyslogSink always returns ErrSyslogUnsupported.
+func NewSyslogSink(tag string) (*SyslogSink, error) {
+	return nil, ErrSyslogUnsupported
+}
+
+// Write always returns ErrSyslogUnsupported.
+func (s *SyslogSink) Write(e *Event) error {
+	return ErrSyslogUnsupported
+}
+
+// Close always returns ErrSyslogUnsupported.
+func (s *SyslogSink) Close() error {
+	return ErrSyslogUnsupported
+}
@@ -0,0 +1,189 @@
+package cryptic
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+// defaultWorkers is the number of concurrent workers used by GetMany when
+// BulkOpts.Workers is not set.
+const defaultWorkers = 8
+
+// ErrSkipped is returned for secrets that were not fetched because GetMany was
+// running in fail-fast mode and an earlier secret failed.
+var ErrSkipped = errors.New("cryptic: skipped due to an earlier error")
+
+// BulkOpts configures the behaviour of GetMany.
+type BulkOpts struct {
+	// Workers is the maximum number of secrets fetched and decrypted
+	// concurrently, defaulting to 8.
+	Workers int
+
+	// FailFast stops fetching secrets after the first error, marking any
+	// secrets not yet processed with ErrSkipped.
+	FailFast bool
+}
+
+// GetMany fetches and decrypts each of names using a bounded pool of workers,
+// returning the plain-text of each secret successfully decrypted, and the error
+// for each secret that was not.
+//
+// If s implements store.BulkGetter, all the secrets are fetched in a single
+// call and only the decryption is performed concurrently. If the bulk fetch
+// fails, the error is returned for every name, unless it is
+// store.ErrBulkUnsupported from a store wrapper, in which case the secrets are
+// fetched individually. Empty names are never passed to the bulk fetch, and
+// are always reported with store.ErrInvalidName.
+//
+// Every name appears in exactly one of the returned maps. opts may be nil to
+// use the defaults (best-effort mode).
+func GetMany(s store.Getter, d encryptor.Decryptor, names []string, opts *BulkOpts) (map[string][]byte, map[string]error) {
+	if opts == nil {
+		opts = &BulkOpts{}
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = defaultWorkers
+	}
+
+	names = uniqueNames(names)
+
+	// fetch returns the EncryptedData for name
+	fetch := s.Get
+
+	if bulk, ok := s.(store.BulkGetter); ok {
+		// An invalid name fails the whole bulk fetch, so they're left out and
+		// reported individually
+		valid := make([]string, 0, len(names))
+		for _, name := range names {
+			if name != "" {
+				valid = append(valid, name)
+			}
+		}
+
+		prefetched, err := bulk.GetMany(valid)
+		switch {
+		case err == store.ErrBulkUnsupported:
+			// A wrapper around a store without bulk reads - fetch each secret
+			// using the workers instead
+
+		case err != nil:
+			errs := map[string]error{}
+			for _, name := range names {
+				errs[name] = err
+				if name == "" {
+					errs[name] = store.ErrInvalidName
+				}
+			}
+
+			return map[string][]byte{}, errs
+
+		default:
+			fetch = func(name string) (*encryptor.EncryptedData, error) {
+				if name == "" {
+					return nil, store.ErrInvalidName
+				}
+
+				data, ok := prefetched[name]
+				if !ok {
+					return nil, store.ErrNotFound
+				}
+
+				return data, nil
+			}
+		}
+	}
+
+	r := &bulkResults{
+		plain:    map[string][]byte{},
+		errs:     map[string]error{},
+		failFast: opts.FailFast,
+	}
+
+	work := make(chan string)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for name := range work {
+				if r.stopped() {
+					r.add(name, nil, ErrSkipped)
+					continue
+				}
+
+				data, err := fetch(name)
+				if err != nil {
+					r.add(name, nil, err)
+					continue
+				}
+
+				plain, err := d.Decrypt(data)
+				r.add(name, plain, err)
+			}
+		}()
+	}
+
+	for _, name := range names {
+		work <- name
+	}
+	close(work)
+
+	wg.Wait()
+
+	return r.plain, r.errs
+}
+
+// bulkResults collects the results of the GetMany workers.
+type bulkResults struct {
+	mu       sync.Mutex
+	plain    map[string][]byte
+	errs     map[string]error
+	failFast bool
+	failed   bool
+}
+
+// add records the result for name.
+func (r *bulkResults) add(name string, plain []byte, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.errs[name] = err
+		r.failed = true
+		return
+	}
+
+	r.plain[name] = plain
+}
+
+// stopped returns true if no more secrets should be fetched.
+func (r *bulkResults) stopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.failFast && r.failed
+}
+
+// uniqueNames returns names with any duplicates removed, preserving order.
+func uniqueNames(names []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(names))
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		out = append(out, name)
+	}
+
+	return out
+}
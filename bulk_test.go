@@ -0,0 +1,213 @@
+package cryptic
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+var errMarker = errors.New("any error")
+
+// getterOnly hides any BulkGetter implementation of the wrapped store.
+type getterOnly struct {
+	store.Getter
+}
+
+// errBulkGetter fails every GetMany call.
+type errBulkGetter struct {
+	store.Getter
+}
+
+func (e errBulkGetter) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	return nil, errMarker
+}
+
+// unsupportedBulkGetter wraps a store without a BulkGetter implementation.
+type unsupportedBulkGetter struct {
+	store.Getter
+}
+
+func (u unsupportedBulkGetter) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	return nil, store.ErrBulkUnsupported
+}
+
+// errDecryptor fails to decrypt the secret with the given ciphertext.
+type errDecryptor struct {
+	fail string
+}
+
+func (e errDecryptor) Decrypt(data *encryptor.EncryptedData) ([]byte, error) {
+	if string(data.Ciphertext) == e.fail {
+		return nil, errMarker
+	}
+
+	return data.Ciphertext, nil
+}
+
+func TestGetMany(t *testing.T) {
+	mem := store.NewMemory()
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("secret%d", i)
+		mem.Put(name, &encryptor.EncryptedData{Ciphertext: []byte(name)})
+	}
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store store.Getter
+		dec   encryptor.Decryptor
+		names []string
+		opts  *BulkOpts
+		// Expected results.
+		want     map[string][]byte
+		wantErrs map[string]error
+	}{
+		{
+			"Bulk",
+			mem,
+			encryptor.NopEncryptor{},
+			[]string{"secret0", "secret1", "secret2", "secret1"},
+			nil,
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+				"secret1": []byte("secret1"),
+				"secret2": []byte("secret2"),
+			},
+			map[string]error{},
+		},
+		{
+			"Single gets",
+			getterOnly{mem},
+			encryptor.NopEncryptor{},
+			[]string{"secret0", "secret1", "secret2"},
+			&BulkOpts{Workers: 2},
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+				"secret1": []byte("secret1"),
+				"secret2": []byte("secret2"),
+			},
+			map[string]error{},
+		},
+		{
+			"Bulk unsupported",
+			unsupportedBulkGetter{mem},
+			encryptor.NopEncryptor{},
+			[]string{"secret0", "missing"},
+			&BulkOpts{Workers: 2},
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+			},
+			map[string]error{
+				"missing": store.ErrNotFound,
+			},
+		},
+		{
+			"Bulk not found",
+			mem,
+			encryptor.NopEncryptor{},
+			[]string{"secret0", "missing"},
+			nil,
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+			},
+			map[string]error{
+				"missing": store.ErrNotFound,
+			},
+		},
+		{
+			"Single gets not found",
+			getterOnly{mem},
+			encryptor.NopEncryptor{},
+			[]string{"secret0", "missing"},
+			nil,
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+			},
+			map[string]error{
+				"missing": store.ErrNotFound,
+			},
+		},
+		{
+			"Decrypt error, best effort",
+			mem,
+			errDecryptor{"secret1"},
+			[]string{"secret0", "secret1", "secret2"},
+			nil,
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+				"secret2": []byte("secret2"),
+			},
+			map[string]error{
+				"secret1": errMarker,
+			},
+		},
+		{
+			"Decrypt error, fail fast",
+			mem,
+			errDecryptor{"secret0"},
+			[]string{"secret0", "secret1", "secret2"},
+			&BulkOpts{Workers: 1, FailFast: true},
+			map[string][]byte{},
+			map[string]error{
+				"secret0": errMarker,
+				"secret1": ErrSkipped,
+				"secret2": ErrSkipped,
+			},
+		},
+		{
+			"Bulk invalid name",
+			mem,
+			encryptor.NopEncryptor{},
+			[]string{"secret0", ""},
+			nil,
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+			},
+			map[string]error{
+				"": store.ErrInvalidName,
+			},
+		},
+		{
+			"Single gets invalid name",
+			getterOnly{mem},
+			encryptor.NopEncryptor{},
+			[]string{"secret0", ""},
+			nil,
+			map[string][]byte{
+				"secret0": []byte("secret0"),
+			},
+			map[string]error{
+				"": store.ErrInvalidName,
+			},
+		},
+		{
+			"Bulk fetch error",
+			errBulkGetter{mem},
+			encryptor.NopEncryptor{},
+			[]string{"secret0", "secret1"},
+			nil,
+			map[string][]byte{},
+			map[string]error{
+				"secret0": errMarker,
+				"secret1": errMarker,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, gotErrs := GetMany(tt.store, tt.dec, tt.names, tt.opts)
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q. GetMany() = %v, want %v", tt.name, got, tt.want)
+		}
+
+		if !reflect.DeepEqual(gotErrs, tt.wantErrs) {
+			t.Errorf("%q. GetMany() errs = %v, want %v", tt.name, gotErrs, tt.wantErrs)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/domodwyer/cryptic/cmd/shared"
+)
+
+var socket = flag.String("socket", "", "path of the agent unix socket")
+
+func init() {
+	flag.Parse()
+}
+
+func main() {
+	if *socket == "" {
+		log.Print("required parameter missing")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	// Ensure only this user can connect to the socket
+	restrictUmask()
+
+	l, err := net.Listen("unix", *socket)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Remove the socket when we're asked to stop
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		l.Close()
+	}()
+
+	agent := &shared.Agent{}
+	if err := agent.Serve(l); err != nil {
+		if _, ok := err.(*net.OpError); !ok {
+			log.Fatal(err)
+		}
+	}
+}
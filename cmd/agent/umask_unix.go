@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import "syscall"
+
+// restrictUmask ensures files (and the socket) created by the agent are only
+// accessible by this user.
+func restrictUmask() {
+	syscall.Umask(0077)
+}
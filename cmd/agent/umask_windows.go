@@ -0,0 +1,5 @@
+package main
+
+// restrictUmask does nothing on Windows, which has no umask - the socket
+// inherits the permissions of the directory it's created in.
+func restrictUmask() {}
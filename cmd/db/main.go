@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/domodwyer/cryptic/cmd/shared"
+	"github.com/domodwyer/cryptic/config"
+)
+
+func init() {
+	flag.Usage = func() {
+		log.Print("usage: db migrate")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+}
+
+func main() {
+	if flag.NArg() != 1 || flag.Arg(0) != "migrate" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Creating the store applies any outstanding migrations
+	if _, err := shared.GetDB(config.New(), true); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Print("OK")
+}
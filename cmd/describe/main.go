@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/domodwyer/cryptic/cmd/shared"
+	"github.com/domodwyer/cryptic/config"
+)
+
+var name = flag.String("name", "", "secret name")
+var namespace = flag.String("namespace", "", "secret namespace (overrides the config file)")
+
+func init() {
+	flag.Parse()
+}
+
+func main() {
+	if *name == "" {
+		log.Print("required parameter missing")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	config := shared.WithNamespace(config.New(), *namespace)
+
+	backend, err := shared.GetStore(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Metadata is never encrypted, so there's no encryptor to wrap
+	backend, _, err = shared.WithAudit(config, backend, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m, err := shared.GetMetadata(config, backend, *name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Name:        %s\n", *name)
+	fmt.Printf("Created:     %s by %s\n", m.CreatedAt.Format(time.RFC3339), m.CreatedBy)
+	fmt.Printf("Updated:     %s\n", m.UpdatedAt.Format(time.RFC3339))
+	fmt.Printf("Description: %s\n", m.Description)
+	fmt.Printf("Labels:      %s\n", shared.Labels(m.Labels))
+}
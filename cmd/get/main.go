@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -8,9 +9,14 @@ import (
 
 	"github.com/domodwyer/cryptic/cmd/shared"
 	"github.com/domodwyer/cryptic/config"
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
 )
 
 var name = flag.String("name", "", "secret name")
+var timeout = flag.Duration("timeout", 0, "abort if not complete within this duration (e.g. 5s)")
+var version = flag.Int("version", 0, "secret version to fetch (defaults to the latest)")
+var namespace = flag.String("namespace", "", "secret namespace (overrides the config file)")
 
 func init() {
 	flag.Parse()
@@ -23,7 +29,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	config := config.New()
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	config := shared.WithNamespace(config.New(), *namespace)
 
 	enc, err := shared.GetEncryptor(config)
 	if err != nil {
@@ -35,15 +48,45 @@ func main() {
 		log.Fatal(err)
 	}
 
-	data, err := backend.Get(*name)
+	backend, enc, err = shared.WithAudit(config, backend, enc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data, err := getSecret(ctx, backend)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	plain, err := enc.Decrypt(data)
+	plain, err := encryptor.WithContext(enc).DecryptContext(ctx, data)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	fmt.Printf("%s", plain)
 }
+
+// getSecret fetches the requested version of the secret from backend.
+func getSecret(ctx context.Context, backend store.Interface) (*encryptor.EncryptedData, error) {
+	if *version == 0 {
+		return store.WithContext(backend).GetContext(ctx, *name)
+	}
+
+	v, ok := backend.(store.Versioner)
+	if !ok {
+		return nil, store.ErrVersioningUnsupported
+	}
+
+	var data *encryptor.EncryptedData
+
+	err := store.RunContext(ctx, func() error {
+		var err error
+		data, err = v.GetVersion(*name, *version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
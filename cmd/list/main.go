@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/domodwyer/cryptic/cmd/shared"
+	"github.com/domodwyer/cryptic/config"
+	"github.com/domodwyer/cryptic/store"
+)
+
+var prefix = flag.String("prefix", "", "only list secrets with names starting with prefix")
+var namespace = flag.String("namespace", "", "secret namespace (overrides the config file)")
+
+var labels = shared.Labels{}
+
+func init() {
+	flag.Var(labels, "label", "only list secrets labelled key=value, may be repeated (requires Metadata.Key)")
+	flag.Parse()
+}
+
+func main() {
+	config := shared.WithNamespace(config.New(), *namespace)
+
+	backend, err := shared.GetStore(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Listing doesn't decrypt anything, so there's no encryptor to wrap
+	backend, _, err = shared.WithAudit(config, backend, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lister, ok := backend.(store.Lister)
+	if !ok {
+		log.Fatal(store.ErrListUnsupported)
+	}
+
+	names, err := store.ListAll(lister, *prefix)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, name := range names {
+		if len(labels) > 0 && !hasLabels(config, backend, name) {
+			continue
+		}
+
+		fmt.Println(name)
+	}
+}
+
+// hasLabels returns true if the metadata of name has all of the requested
+// labels. Secrets with metadata that fails verification are skipped.
+func hasLabels(config config.Metadata, backend store.Interface, name string) bool {
+	m, err := shared.GetMetadata(config, backend, name)
+	switch err {
+	case nil:
+		return m.HasLabels(labels)
+
+	case store.ErrNoMetadata, store.ErrNotFound:
+		// Deleted since listing, or never described
+		return false
+
+	case store.ErrInvalidMetadata:
+		log.Printf("skipping %q: %s", name, err)
+		return false
+
+	default:
+		log.Fatal(err)
+	}
+
+	return false
+}
@@ -1,18 +1,31 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/domodwyer/cryptic/cmd/shared"
 	"github.com/domodwyer/cryptic/config"
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
 )
 
 var name = flag.String("name", "", "secret name")
 var data = flag.String("value", "", "secret value")
+var timeout = flag.Duration("timeout", 0, "abort if not complete within this duration (e.g. 5s)")
+var update = flag.Bool("update", false, "store the value as a new version of an existing secret")
+var ttl = flag.Duration("ttl", 0, "remove the secret after this duration (e.g. 24h)")
+var overwrite = flag.Bool("overwrite", false, "replace the secret if it already exists")
+var description = flag.String("description", "", "describe the secret (requires Metadata.Key)")
+var namespace = flag.String("namespace", "", "secret namespace (overrides the config file)")
+
+var labels = shared.Labels{}
 
 func init() {
+	flag.Var(labels, "label", "label the secret with key=value, may be repeated (requires Metadata.Key)")
 	flag.Parse()
 }
 
@@ -23,7 +36,27 @@ func main() {
 		os.Exit(1)
 	}
 
-	config := config.New()
+	if *ttl > 0 && (*update || *overwrite) {
+		log.Fatal("-ttl can't be used with -update or -overwrite")
+	}
+
+	if *update && *overwrite {
+		log.Fatal("-update can't be used with -overwrite")
+	}
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	config := shared.WithNamespace(config.New(), *namespace)
+
+	describe := config.MetadataKey() != ""
+	if !describe && (*description != "" || len(labels) > 0) {
+		log.Fatal(shared.ErrMetadataDisabled)
+	}
 
 	enc, err := shared.GetEncryptor(config)
 	if err != nil {
@@ -35,14 +68,100 @@ func main() {
 		log.Fatal(err)
 	}
 
-	e, err := enc.Encrypt([]byte(*data))
+	backend, enc, err = shared.WithAudit(config, backend, enc)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if err := backend.Put(*name, e); err != nil {
+	// Keep the existing metadata when replacing a secret
+	var prev *store.Metadata
+	if describe && (*update || *overwrite) {
+		prev, err = shared.GetMetadata(config, backend, *name)
+		switch err {
+		case nil, store.ErrNotFound, store.ErrNoMetadata:
+			break
+
+		default:
+			log.Fatal(err)
+		}
+	}
+
+	e, err := encryptor.WithContext(enc).EncryptContext(ctx, []byte(*data))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	status, err := putSecret(ctx, backend, e)
+	if err != nil {
 		log.Fatal(err)
 	}
 
-	log.Print("OK")
+	if describe {
+		if err := shared.PutMetadata(config, backend, *name, prev, *description, labels); err != nil {
+			log.Fatalf("secret stored, but storing metadata failed: %s", err)
+		}
+	}
+
+	log.Print(status)
+}
+
+// putSecret stores e as requested by the command line flags, returning the
+// status to print.
+func putSecret(ctx context.Context, backend store.Interface, e *encryptor.EncryptedData) (string, error) {
+	switch {
+	case *update:
+		v, ok := backend.(store.Versioner)
+		if !ok {
+			return "", store.ErrVersioningUnsupported
+		}
+
+		var version int
+
+		err := store.RunContext(ctx, func() error {
+			var err error
+			version, err = v.PutVersion(*name, e)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("OK (version %d)", version), nil
+
+	case *ttl > 0:
+		x, ok := backend.(store.Expirer)
+		if !ok {
+			return "", store.ErrExpiryUnsupported
+		}
+
+		err := store.RunContext(ctx, func() error {
+			return x.PutWithTTL(*name, e, *ttl)
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("OK (expires in %s)", *ttl), nil
+
+	case *overwrite:
+		u, ok := backend.(store.Updater)
+		if !ok {
+			return "", store.ErrUpdateUnsupported
+		}
+
+		err := store.RunContext(ctx, func() error {
+			return u.Upsert(*name, e)
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return "OK", nil
+	}
+
+	if err := store.WithContext(backend).PutContext(ctx, *name, e); err != nil {
+		return "", err
+	}
+
+	return "OK", nil
 }
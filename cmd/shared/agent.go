@@ -0,0 +1,219 @@
+package shared
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// agentDialTimeout bounds how long clients wait to connect to the agent.
+const agentDialTimeout = time.Second
+
+// errAgentEmpty is returned by agentGet when the agent holds no passphrase.
+var errAgentEmpty = errors.New("agent: no cached passphrase")
+
+// Agent holds a passphrase in memory for a limited time, serving it to clients
+// connected to a unix socket so users are not prompted on every invocation.
+//
+// The cached passphrase is overwritten in memory once it expires.
+//
+// Clients send a single line command, and receive a single line response:
+//
+//	GET                   -> "OK <base64 passphrase>" or "NONE"
+//	PUT <ttl secs> <b64>  -> "OK"
+//	CLEAR                 -> "OK"
+type Agent struct {
+	mu         sync.Mutex
+	passphrase []byte
+	timer      *time.Timer
+	generation int
+}
+
+// Serve accepts connections on l until it returns an error.
+func (a *Agent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go a.handle(conn)
+	}
+}
+
+// handle processes a single client command.
+func (a *Agent) handle(conn net.Conn) {
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	args := strings.Fields(line)
+	if len(args) < 1 {
+		fmt.Fprintln(conn, "ERR")
+		return
+	}
+
+	switch {
+	case args[0] == "GET" && len(args) == 1:
+		p := a.get()
+		if p == nil {
+			fmt.Fprintln(conn, "NONE")
+			return
+		}
+
+		fmt.Fprintf(conn, "OK %s\n", base64.StdEncoding.EncodeToString(p))
+
+	case args[0] == "PUT" && len(args) == 3:
+		secs, err := strconv.Atoi(args[1])
+		if err != nil || secs < 1 {
+			fmt.Fprintln(conn, "ERR")
+			return
+		}
+
+		p, err := base64.StdEncoding.DecodeString(args[2])
+		if err != nil {
+			fmt.Fprintln(conn, "ERR")
+			return
+		}
+
+		a.put(p, time.Duration(secs)*time.Second)
+		fmt.Fprintln(conn, "OK")
+
+	case args[0] == "CLEAR" && len(args) == 1:
+		a.clear()
+		fmt.Fprintln(conn, "OK")
+
+	default:
+		fmt.Fprintln(conn, "ERR")
+	}
+}
+
+// get returns a copy of the cached passphrase, or nil if there is none.
+func (a *Agent) get() []byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.passphrase == nil {
+		return nil
+	}
+
+	return append([]byte{}, a.passphrase...)
+}
+
+// put caches p for ttl, replacing any existing passphrase.
+func (a *Agent) put(p []byte, ttl time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.wipe()
+
+	a.passphrase = p
+	a.generation++
+
+	// Only expire this passphrase, and not one that replaces it
+	gen := a.generation
+	a.timer = time.AfterFunc(ttl, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		if a.generation == gen {
+			a.wipe()
+		}
+	})
+}
+
+// clear removes the cached passphrase.
+func (a *Agent) clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.wipe()
+}
+
+// wipe overwrites and removes the cached passphrase, and must be called with mu
+// held.
+func (a *Agent) wipe() {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+
+	for i := range a.passphrase {
+		a.passphrase[i] = 0
+	}
+
+	a.passphrase = nil
+}
+
+// agentGet fetches the cached passphrase from the agent listening on socket.
+func agentGet(socket string) ([]byte, error) {
+	resp, err := agentCall(socket, "GET")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp == "NONE" {
+		return nil, errAgentEmpty
+	}
+
+	if !strings.HasPrefix(resp, "OK ") {
+		return nil, fmt.Errorf("agent: unexpected response %q", resp)
+	}
+
+	return base64.StdEncoding.DecodeString(resp[3:])
+}
+
+// agentPut caches passphrase in the agent listening on socket for ttl.
+func agentPut(socket string, ttl time.Duration, passphrase []byte) error {
+	secs := int(ttl / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	cmd := fmt.Sprintf("PUT %d %s", secs, base64.StdEncoding.EncodeToString(passphrase))
+
+	resp, err := agentCall(socket, cmd)
+	if err != nil {
+		return err
+	}
+
+	if resp != "OK" {
+		return fmt.Errorf("agent: unexpected response %q", resp)
+	}
+
+	return nil
+}
+
+// agentCall sends cmd to the agent listening on socket, returning the response
+// line.
+func agentCall(socket, cmd string) (string, error) {
+	conn, err := net.DialTimeout("unix", socket, agentDialTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(agentDialTimeout))
+
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		return "", err
+	}
+
+	resp, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(resp, "\r\n"), nil
+}
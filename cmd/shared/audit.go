@@ -0,0 +1,64 @@
+package shared
+
+import (
+	"errors"
+	"os"
+	"os/user"
+
+	"github.com/domodwyer/cryptic/audit"
+	"github.com/domodwyer/cryptic/config"
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+// WithAudit wraps backend and enc to record audit events to the configured
+// sink. If audit logging is disabled, backend and enc are returned unchanged.
+func WithAudit(config config.Audit, backend store.Interface, enc encryptor.EncryptDecryptor) (store.Interface, encryptor.EncryptDecryptor, error) {
+	sink, err := getAuditSink(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if sink == nil {
+		return backend, enc, nil
+	}
+
+	actor := auditActor(config)
+
+	return audit.NewStore(backend, sink, actor), audit.NewEncryptor(enc, sink, actor), nil
+}
+
+// getAuditSink returns the configured audit.Sink, or nil if audit logging is
+// disabled.
+func getAuditSink(config config.Audit) (audit.Sink, error) {
+	switch config.AuditSink() {
+	case "":
+		return nil, nil
+
+	case "file":
+		return audit.NewFileSink(config.AuditFile())
+
+	case "syslog":
+		return audit.NewSyslogSink(config.AuditSyslogTag())
+
+	case "stderr":
+		return audit.NewWriterSink(os.Stderr), nil
+
+	default:
+		return nil, errors.New("unknown audit sink")
+	}
+}
+
+// auditActor returns the configured actor name, falling back to the name of
+// the user running the process.
+func auditActor(config config.Audit) string {
+	if actor := config.AuditActor(); actor != "" {
+		return actor
+	}
+
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
@@ -1,13 +1,33 @@
 package shared
 
+import "time"
+
 type mockConfig struct {
-	store      string
-	encryptor  string
-	kmsKeyID   string
-	kmsRegion  string
-	aesKey     string
-	aesHmacKey string
-	kdfKey     string
+	store          string
+	encryptor      string
+	kmsKeyID       string
+	kmsRegion      string
+	aesKey         string
+	aesHmacKey     string
+	kdfKey         string
+	kdfKeySource   string
+	kdfKeyFD       int
+	agentSocket    string
+	agentCacheTTL  time.Duration
+	keyringService string
+	keyringUser    string
+	cascadeLayers  []string
+	kdfCacheSize   int
+	metadataKey    string
+	namespace      string
+}
+
+func (m mockConfig) Namespace() string {
+	return m.namespace
+}
+
+func (m mockConfig) MetadataKey() string {
+	return m.metadataKey
 }
 
 func (m mockConfig) Store() string {
@@ -37,3 +57,35 @@ func (m mockConfig) AESHmacKey() string {
 func (m mockConfig) KDFKey() string {
 	return m.kdfKey
 }
+
+func (m mockConfig) KDFKeySource() string {
+	return m.kdfKeySource
+}
+
+func (m mockConfig) KDFKeyFD() int {
+	return m.kdfKeyFD
+}
+
+func (m mockConfig) KDFKeyringService() string {
+	return m.keyringService
+}
+
+func (m mockConfig) KDFKeyringUser() string {
+	return m.keyringUser
+}
+
+func (m mockConfig) AgentSocket() string {
+	return m.agentSocket
+}
+
+func (m mockConfig) AgentCacheTTL() time.Duration {
+	return m.agentCacheTTL
+}
+
+func (m mockConfig) CascadeLayers() []string {
+	return m.cascadeLayers
+}
+
+func (m mockConfig) KDFCacheSize() int {
+	return m.kdfCacheSize
+}
@@ -2,6 +2,7 @@ package shared
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/domodwyer/cryptic/config"
 	"github.com/domodwyer/cryptic/encryptor"
@@ -12,34 +13,124 @@ import (
 func GetEncryptor(config config.Encryptor) (encryptor.EncryptDecryptor, error) {
 	switch config.Encryptor() {
 	case "aes-pbkdf2":
-		return encryptor.NewKDF([]byte(config.KDFKey()))
+		return getKDF(config, nil)
 
 	case "aes":
 		return encryptor.NewAES([]byte(config.AESKey()), []byte(config.AESHmacKey()))
 
 	case "aes-gcm-pbkdf2":
-		enc, err := encryptor.NewKDF([]byte(config.KDFKey()))
-		if err != nil {
-			return nil, err
-		}
-
 		// Set the encryption provider to AESGCM
-		enc.Provider = func(key []byte) (encryptor.EncryptDecryptor, error) {
+		return getKDF(config, func(key []byte) (encryptor.EncryptDecryptor, error) {
 			return encryptor.NewAESGCM(key[:32])
-		}
+		})
 
-		return enc, nil
+	case "aes-gcm-commit-pbkdf2":
+		// Set the encryption provider to key-committing AESGCM
+		return getKDF(config, func(key []byte) (encryptor.EncryptDecryptor, error) {
+			return encryptor.NewAESGCMCommit(key[:32])
+		})
 
 	case "aes-gcm":
 		return encryptor.NewAESGCM([]byte(config.AESKey()))
 
+	case "aes-gcm-commit":
+		return encryptor.NewAESGCMCommit([]byte(config.AESKey()))
+
 	case "kms":
 		if config.KMSKeyID() == "" {
 			return nil, errors.New("kms: No key ID set")
 		}
 		return encryptor.NewKMS(config.KMSKeyID(), config.KMSRegion()), nil
 
+	case "kms-gcm-commit":
+		if config.KMSKeyID() == "" {
+			return nil, errors.New("kms: No key ID set")
+		}
+
+		enc := encryptor.NewKMS(config.KMSKeyID(), config.KMSRegion())
+
+		// Set the encryption provider to key-committing AESGCM
+		enc.Provider = func(key []byte) (encryptor.EncryptDecryptor, error) {
+			if len(key) < 32 {
+				return nil, encryptor.ErrKeyTooShort
+			}
+
+			return encryptor.NewAESGCMCommit(key[:32])
+		}
+
+		return enc, nil
+
+	case "kms-direct":
+		if config.KMSKeyID() == "" {
+			return nil, errors.New("kms: No key ID set")
+		}
+		return encryptor.NewKMSDirect(config.KMSKeyID(), config.KMSRegion()), nil
+
+	case "cascade":
+		return getCascade(config)
+
 	default:
 		return nil, errors.New("unknown decryptor")
 	}
 }
+
+// getKDF returns a KDF Encryptor using the configured key source and key cache,
+// and provider if not nil.
+func getKDF(config config.Encryptor, provider encryptor.EncryptionProvider) (encryptor.EncryptDecryptor, error) {
+	key, err := getKDFKey(config)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := encryptor.NewKDF(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider != nil {
+		enc.Provider = provider
+	}
+
+	if config.KDFCacheSize() > 0 {
+		enc.Cache = encryptor.NewKeyCache(config.KDFCacheSize())
+	}
+
+	return enc, nil
+}
+
+// layerConfig overrides the Encryptor type name of the embedded config,
+// allowing each layer of a Cascade to be built with GetEncryptor.
+type layerConfig struct {
+	parentConfig
+	name string
+}
+
+// parentConfig allows config.Encryptor to be embedded in layerConfig without
+// the embedded field name clashing with the Encryptor method.
+type parentConfig config.Encryptor
+
+// Encryptor returns the type name of this layer.
+func (c layerConfig) Encryptor() string {
+	return c.name
+}
+
+// getCascade returns a Cascade Encryptor built from the configured layers.
+func getCascade(config config.Encryptor) (encryptor.EncryptDecryptor, error) {
+	layers := []encryptor.EncryptDecryptor{}
+
+	for _, name := range config.CascadeLayers() {
+		name = strings.ToLower(name)
+		if name == "cascade" {
+			return nil, errors.New("cascade: layers cannot be a cascade")
+		}
+
+		enc, err := GetEncryptor(layerConfig{config, name})
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, enc)
+	}
+
+	return encryptor.NewCascade(layers...)
+}
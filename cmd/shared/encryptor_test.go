@@ -129,6 +129,15 @@ func TestGetEncryptor_KDF(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"KDF with cache",
+			mockConfig{
+				encryptor:    "aes-pbkdf2",
+				kdfKey:       "ok",
+				kdfCacheSize: 10,
+			},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		got, err := GetEncryptor(tt.config)
@@ -145,3 +154,173 @@ func TestGetEncryptor_KDF(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEncryptor_KMSDirect(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		config config.Encryptor
+		// Expected results.
+		wantErr bool
+	}{
+		{
+			"KMS direct",
+			mockConfig{
+				encryptor: "kms-direct",
+				kmsKeyID:  "keyID",
+				kmsRegion: "eu-west-1",
+			},
+			false,
+		},
+		{
+			"KMS direct no Key ID",
+			mockConfig{
+				encryptor: "kms-direct",
+				kmsKeyID:  "",
+				kmsRegion: "eu-west-1",
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := GetEncryptor(tt.config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q. getEncryptor() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if _, ok := got.(*encryptor.KMSDirectEncryptor); !ok {
+			t.Errorf("%q. getEncryptor() not correct type", tt.name)
+		}
+	}
+}
+
+func TestGetEncryptor_GCMCommit(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		config config.Encryptor
+		// Expected results.
+		wantType uint8
+	}{
+		{
+			"AES-GCM commit",
+			mockConfig{
+				encryptor: "aes-gcm-commit",
+				aesKey:    "1234567890123456",
+			},
+			encryptor.AESGCMCommit,
+		},
+		{
+			"AES-GCM commit KDF",
+			mockConfig{
+				encryptor: "aes-gcm-commit-pbkdf2",
+				kdfKey:    "ok",
+			},
+			encryptor.Pbkdf2,
+		},
+	}
+	for _, tt := range tests {
+		got, err := GetEncryptor(tt.config)
+		if err != nil {
+			t.Errorf("%q. getEncryptor() error = %v", tt.name, err)
+			continue
+		}
+
+		data, err := got.Encrypt([]byte("secret"))
+		if err != nil {
+			t.Errorf("%q. Encrypt() error = %v", tt.name, err)
+			continue
+		}
+
+		if data.Type != tt.wantType {
+			t.Errorf("%q. Encrypt() type = %v, want %v", tt.name, data.Type, tt.wantType)
+		}
+	}
+
+	got, err := GetEncryptor(mockConfig{encryptor: "kms-gcm-commit", kmsKeyID: "keyID"})
+	if err != nil {
+		t.Fatalf("KMS GCM commit. getEncryptor() error = %v", err)
+	}
+
+	if _, ok := got.(*encryptor.KMS); !ok {
+		t.Errorf("KMS GCM commit. getEncryptor() not correct type")
+	}
+}
+
+func TestGetEncryptor_Cascade(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		config config.Encryptor
+		// Expected results.
+		wantLayers int
+		wantErr    bool
+	}{
+		{
+			"Cascade",
+			mockConfig{
+				encryptor:     "cascade",
+				aesKey:        "1234567890123456",
+				kmsKeyID:      "keyID",
+				kmsRegion:     "eu-west-1",
+				cascadeLayers: []string{"aes-gcm", "KMS"},
+			},
+			2,
+			false,
+		},
+		{
+			"No layers",
+			mockConfig{
+				encryptor: "cascade",
+			},
+			0,
+			true,
+		},
+		{
+			"Nested cascade",
+			mockConfig{
+				encryptor:     "cascade",
+				cascadeLayers: []string{"cascade"},
+			},
+			0,
+			true,
+		},
+		{
+			"Layer error",
+			mockConfig{
+				encryptor:     "cascade",
+				aesKey:        "short",
+				cascadeLayers: []string{"aes-gcm"},
+			},
+			0,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := GetEncryptor(tt.config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q. getEncryptor() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		c, ok := got.(*encryptor.Cascade)
+		if !ok {
+			t.Errorf("%q. getEncryptor() not correct type", tt.name)
+			continue
+		}
+
+		if len(c.Layers) != tt.wantLayers {
+			t.Errorf("%q. getEncryptor() layers = %d, want %d", tt.name, len(c.Layers), tt.wantLayers)
+		}
+	}
+}
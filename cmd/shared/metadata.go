@@ -0,0 +1,147 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/domodwyer/cryptic/config"
+	"github.com/domodwyer/cryptic/store"
+)
+
+// ErrMetadataDisabled is returned when using secret metadata without a
+// Metadata.Key configured.
+var ErrMetadataDisabled = errors.New("metadata is disabled (set Metadata.Key)")
+
+// Labels is a flag.Value collecting key=value pairs, for use with a repeated
+// flag (i.e. -label=env=prod -label=team=payments).
+type Labels map[string]string
+
+// String returns the labels as comma separated key=value pairs, sorted by key.
+func (l Labels) String() string {
+	pairs := make([]string, 0, len(l))
+	for k, v := range l {
+		pairs = append(pairs, k+"="+v)
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// Set parses a single key=value pair.
+func (l Labels) Set(v string) error {
+	parts := strings.SplitN(v, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid label %q, expected key=value", v)
+	}
+
+	l[parts[0]] = parts[1]
+	return nil
+}
+
+// GetMetadata fetches the metadata of name from backend, returning
+// store.ErrInvalidMetadata if it fails verification.
+func GetMetadata(config config.Metadata, backend store.Interface, name string) (*store.Metadata, error) {
+	key := config.MetadataKey()
+	if key == "" {
+		return nil, ErrMetadataDisabled
+	}
+
+	d, ok := backend.(store.Describer)
+	if !ok {
+		return nil, store.ErrMetadataUnsupported
+	}
+
+	m, err := d.GetMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Verify([]byte(key), signedName(config, name)); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// PutMetadata signs and stores the metadata of name in backend.
+//
+// If prev is not nil, the creation time, creator, description and labels are
+// kept from prev, with description and labels replacing those in prev when
+// set.
+func PutMetadata(config config.Metadata, backend store.Interface, name string, prev *store.Metadata, description string, labels Labels) error {
+	key := config.MetadataKey()
+	if key == "" {
+		return ErrMetadataDisabled
+	}
+
+	d, ok := backend.(store.Describer)
+	if !ok {
+		return store.ErrMetadataUnsupported
+	}
+
+	now := time.Now().UTC()
+
+	m := &store.Metadata{
+		CreatedAt: now,
+		CreatedBy: creator(),
+		Labels:    map[string]string{},
+	}
+
+	if prev != nil {
+		m.CreatedAt = prev.CreatedAt
+		m.CreatedBy = prev.CreatedBy
+		m.Description = prev.Description
+
+		for k, v := range prev.Labels {
+			m.Labels[k] = v
+		}
+	}
+
+	m.UpdatedAt = now
+
+	if description != "" {
+		m.Description = description
+	}
+
+	for k, v := range labels {
+		m.Labels[k] = v
+	}
+
+	if err := m.Sign([]byte(key), signedName(config, name)); err != nil {
+		return err
+	}
+
+	return d.PutMetadata(name, m)
+}
+
+// signedName returns the name metadata is signed with, including the configured
+// namespace so metadata copied to the same name in another namespace fails
+// verification.
+func signedName(config config.Metadata, name string) string {
+	if ns := config.Namespace(); ns != "" {
+		return ns + "\x00" + name
+	}
+
+	return name
+}
+
+// creator returns the name of the user running the process, and the host name
+// in the form user@host.
+func creator() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return name
+	}
+
+	return name + "@" + host
+}
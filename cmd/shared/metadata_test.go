@@ -0,0 +1,147 @@
+package shared
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+func TestLabelsSet(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		values []string
+		// Expected results.
+		want    Labels
+		wantErr bool
+	}{
+		{
+			"Single",
+			[]string{"env=prod"},
+			Labels{"env": "prod"},
+			false,
+		},
+		{
+			"Repeated",
+			[]string{"env=prod", "team=payments", "env=staging"},
+			Labels{"env": "staging", "team": "payments"},
+			false,
+		},
+		{
+			"Value with equals",
+			[]string{"query=a=b"},
+			Labels{"query": "a=b"},
+			false,
+		},
+		{
+			"No value",
+			[]string{"env"},
+			Labels{},
+			true,
+		},
+		{
+			"No key",
+			[]string{"=prod"},
+			Labels{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		got := Labels{}
+
+		var err error
+		for _, v := range tt.values {
+			if err = got.Set(v); err != nil {
+				break
+			}
+		}
+
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q. Labels.Set() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q. Labels.Set() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestPutMetadata ensures metadata is signed, and updates keep the creator.
+func TestPutMetadata(t *testing.T) {
+	config := mockConfig{metadataKey: "key"}
+
+	s := store.NewMemory()
+	s.Put("secret", &encryptor.EncryptedData{})
+
+	if err := PutMetadata(config, s, "secret", nil, "first", Labels{"env": "prod"}); err != nil {
+		t.Fatalf("PutMetadata() error = %v", err)
+	}
+
+	first, err := GetMetadata(config, s, "secret")
+	if err != nil {
+		t.Fatalf("GetMetadata() error = %v", err)
+	}
+
+	if first.Description != "first" || first.CreatedBy == "" {
+		t.Errorf("GetMetadata() = %+v, want description and creator", first)
+	}
+
+	prev := *first
+	prev.CreatedAt = time.Unix(0, 0).UTC()
+
+	if err := PutMetadata(config, s, "secret", &prev, "", Labels{"team": "payments"}); err != nil {
+		t.Fatalf("PutMetadata() update error = %v", err)
+	}
+
+	got, err := GetMetadata(config, s, "secret")
+	if err != nil {
+		t.Fatalf("GetMetadata() error = %v", err)
+	}
+
+	if !got.CreatedAt.Equal(prev.CreatedAt) || got.Description != "first" {
+		t.Errorf("GetMetadata() = %+v, want created at and description kept", got)
+	}
+
+	want := map[string]string{"env": "prod", "team": "payments"}
+	if !reflect.DeepEqual(got.Labels, want) {
+		t.Errorf("GetMetadata() labels = %v, want %v", got.Labels, want)
+	}
+
+	if _, err := GetMetadata(mockConfig{metadataKey: "other"}, s, "secret"); err != store.ErrInvalidMetadata {
+		t.Errorf("GetMetadata() wrong key error = %v, want %v", err, store.ErrInvalidMetadata)
+	}
+
+	if _, err := GetMetadata(mockConfig{}, s, "secret"); err != ErrMetadataDisabled {
+		t.Errorf("GetMetadata() no key error = %v, want %v", err, ErrMetadataDisabled)
+	}
+}
+
+// TestMetadataNamespace ensures metadata signed in one namespace fails
+// verification in another.
+func TestMetadataNamespace(t *testing.T) {
+	prod := mockConfig{metadataKey: "key", namespace: "prod"}
+
+	s := store.NewMemory()
+	s.Put("secret", &encryptor.EncryptedData{})
+
+	if err := PutMetadata(prod, s, "secret", nil, "prod", nil); err != nil {
+		t.Fatalf("PutMetadata() error = %v", err)
+	}
+
+	if _, err := GetMetadata(prod, s, "secret"); err != nil {
+		t.Errorf("GetMetadata() error = %v", err)
+	}
+
+	for _, ns := range []string{"staging", ""} {
+		config := mockConfig{metadataKey: "key", namespace: ns}
+		if _, err := GetMetadata(config, s, "secret"); err != store.ErrInvalidMetadata {
+			t.Errorf("GetMetadata() namespace %q error = %v, want %v", ns, err, store.ErrInvalidMetadata)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/domodwyer/cryptic/config"
+)
+
+// readTTY prompts for a passphrase on the controlling terminal, without echo.
+//
+// It is a variable so tests can replace it.
+var readTTY = func() ([]byte, error) {
+	// Use the terminal directly so stdout can be redirected, falling back to
+	// stdin if there's no /dev/tty (i.e. Windows)
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		tty = os.Stdin
+	} else {
+		defer tty.Close()
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	defer fmt.Fprintln(os.Stderr)
+
+	return terminal.ReadPassword(int(tty.Fd()))
+}
+
+// getKDFKey returns the source key for the pbkdf2 encryptors, read from the
+// configured key source.
+func getKDFKey(config config.KDF) ([]byte, error) {
+	switch config.KDFKeySource() {
+	case "", "config":
+		return []byte(config.KDFKey()), nil
+
+	case "fd":
+		f := os.NewFile(uintptr(config.KDFKeyFD()), "passphrase")
+		if f == nil {
+			return nil, errors.New("invalid passphrase file descriptor")
+		}
+		defer f.Close()
+
+		return readPassphrase(f)
+
+	case "keyring":
+		p, err := keyring.Get(config.KDFKeyringService(), config.KDFKeyringUser())
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(p), nil
+
+	case "prompt":
+		return promptPassphrase(config)
+
+	default:
+		return nil, errors.New("unknown key source")
+	}
+}
+
+// promptPassphrase returns the passphrase cached by the agent if configured,
+// or prompts the user for it, caching the result in the agent.
+func promptPassphrase(config config.KDF) ([]byte, error) {
+	socket := config.AgentSocket()
+
+	if socket != "" {
+		if p, err := agentGet(socket); err == nil {
+			return p, nil
+		}
+	}
+
+	p, err := readTTY()
+	if err != nil {
+		return nil, err
+	}
+
+	if socket != "" && config.AgentCacheTTL() > 0 {
+		// Failing to cache the passphrase is annoying, but not fatal
+		if err := agentPut(socket, config.AgentCacheTTL(), p); err != nil {
+			log.Printf("agent: failed to cache passphrase: %s", err)
+		}
+	}
+
+	return p, nil
+}
+
+// readPassphrase reads a single line from r, without the line ending.
+func readPassphrase(r io.Reader) ([]byte, error) {
+	line, err := bufio.NewReader(r).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return bytes.TrimRight(line, "\r\n"), nil
+}
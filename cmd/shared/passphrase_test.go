@@ -0,0 +1,146 @@
+package shared
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetKDFKey(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+
+	w.Write([]byte("piped passphrase\r\nignored"))
+	w.Close()
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		config mockConfig
+		// Expected results.
+		want    []byte
+		wantErr bool
+	}{
+		{
+			"Default",
+			mockConfig{kdfKey: "from config"},
+			[]byte("from config"),
+			false,
+		},
+		{
+			"Config",
+			mockConfig{kdfKey: "from config", kdfKeySource: "config"},
+			[]byte("from config"),
+			false,
+		},
+		{
+			"FD",
+			mockConfig{kdfKey: "from config", kdfKeySource: "fd", kdfKeyFD: int(r.Fd())},
+			[]byte("piped passphrase"),
+			false,
+		},
+		{
+			"Unknown",
+			mockConfig{kdfKey: "from config", kdfKeySource: "carrier pigeon"},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := getKDFKey(tt.config)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q. getKDFKey() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%q. getKDFKey() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestGetKDFKey_PromptAgent ensures a prompted passphrase is cached in the
+// agent, and the user is not prompted again.
+func TestGetKDFKey_PromptAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cryptic")
+	if err != nil {
+		t.Fatalf("TempDir() error = %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "agent.sock")
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer l.Close()
+
+	go (&Agent{}).Serve(l)
+
+	defer func(orig func() ([]byte, error)) { readTTY = orig }(readTTY)
+
+	prompts := 0
+	readTTY = func() ([]byte, error) {
+		prompts++
+		return []byte("typed passphrase"), nil
+	}
+
+	config := mockConfig{
+		kdfKeySource:  "prompt",
+		agentSocket:   socket,
+		agentCacheTTL: time.Minute,
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := getKDFKey(config)
+		if err != nil {
+			t.Fatalf("getKDFKey() error = %v", err)
+		}
+
+		if !bytes.Equal(got, []byte("typed passphrase")) {
+			t.Errorf("getKDFKey() = %q, want %q", got, "typed passphrase")
+		}
+	}
+
+	if prompts != 1 {
+		t.Errorf("getKDFKey() prompted %d times, want 1", prompts)
+	}
+
+	// Without an agent, every call prompts
+	config.agentSocket = ""
+	getKDFKey(config)
+
+	if prompts != 2 {
+		t.Errorf("getKDFKey() without agent prompted %d times, want 2", prompts)
+	}
+}
+
+// TestAgentExpiry ensures the cached passphrase is wiped once it expires.
+func TestAgentExpiry(t *testing.T) {
+	a := &Agent{}
+
+	p := []byte("secret")
+	a.put(p, 10*time.Millisecond)
+
+	if got := a.get(); !bytes.Equal(got, []byte("secret")) {
+		t.Fatalf("Agent.get() = %q, want %q", got, "secret")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := a.get(); got != nil {
+		t.Errorf("Agent.get() after expiry = %q, want nil", got)
+	}
+
+	if !bytes.Equal(p, make([]byte, len(p))) {
+		t.Errorf("Agent did not wipe expired passphrase, got %q", p)
+	}
+}
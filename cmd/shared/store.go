@@ -4,14 +4,19 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"net/url"
 
+	bolt "go.etcd.io/bbolt"
+	clientv3 "go.etcd.io/etcd/client/v3"
 	"gopkg.in/redis.v4"
 
 	"github.com/domodwyer/cryptic/config"
 	"github.com/domodwyer/cryptic/store"
 
-	// Import the MySQL driver
+	// Import the supported database drivers
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // GetStore returns a concrete type that implements store.Interface based on the
@@ -20,30 +25,40 @@ func GetStore(config config.Store) (store.Interface, error) {
 	var backend store.Interface
 	var err error
 
+	// Set when the store keeps namespaces apart itself
+	namespaced := false
+
 	switch config.Store() {
 	case "db":
-		// Compose the DSN
-		dsn := fmt.Sprintf("%s:%s@tcp(%s)]/%s",
-			config.DBUsername(),
-			config.DBPassword(),
-			config.DBHost(),
-			config.DBName(),
-		)
+		backend, err = GetDB(config, false)
 
-		// Connect to DB
-		db, err2 := sql.Open("mysql", dsn)
-		if err2 != nil {
-			return nil, err2
-		}
+	case "file":
+		backend, err = store.NewFile(config.FilePath())
 
-		// Configure DB store
-		opts := &store.DBOpts{
-			Table: config.DBTable(),
-			Key:   config.DBKeyColumn(),
-			Value: config.DBValueColumn(),
-		}
+	case "bolt":
+		backend, err = getBolt(config)
+		namespaced = true
+
+	case "s3":
+		backend, err = store.NewS3(&store.S3Opts{
+			Bucket:               config.S3Bucket(),
+			Region:               config.S3Region(),
+			Prefix:               config.S3Prefix(),
+			Endpoint:             config.S3Endpoint(),
+			ServerSideEncryption: config.S3ServerSideEncryption(),
+			KMSKeyID:             config.S3KMSKeyID(),
+		})
 
-		backend, err = store.NewDB(db, opts)
+	case "dynamodb":
+		backend, err = store.NewDynamoDB(&store.DynamoDBOpts{
+			Table:     config.DynamoDBTable(),
+			Region:    config.DynamoDBRegion(),
+			Endpoint:  config.DynamoDBEndpoint(),
+			Credstash: config.DynamoDBCredstash(),
+		})
+
+	case "etcd":
+		backend, err = getEtcd(config)
 
 	case "redis":
 		backend = store.NewRedis(&redis.Options{
@@ -63,5 +78,152 @@ func GetStore(config config.Store) (store.Interface, error) {
 		return nil, err
 	}
 
-	return backend, nil
+	if ns := config.Namespace(); ns != "" && !namespaced {
+		backend, err = store.NewNamespaced(backend, ns)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return blindStore(config, backend)
+}
+
+// GetDB returns the DB store configured in the config file, applying any
+// schema migrations first if migrate is true or DB.Migrate is set.
+func GetDB(config config.DB, migrate bool) (*store.DB, error) {
+	dialect, err := store.DialectFor(config.DBDriver())
+	if err != nil {
+		return nil, err
+	}
+
+	// Connect to DB
+	db, err := sql.Open(dialect.Name(), getDSN(config))
+	if err != nil {
+		return nil, err
+	}
+
+	// Configure DB store
+	opts := &store.DBOpts{
+		Table:   config.DBTable(),
+		Key:     config.DBKeyColumn(),
+		Value:   config.DBValueColumn(),
+		Dialect: dialect,
+
+		VersionTable:   config.DBVersionTable(),
+		ExpiresColumn:  config.DBExpiresColumn(),
+		MetadataColumn: config.DBMetadataColumn(),
+
+		Migrate: migrate || config.DBMigrate(),
+	}
+
+	return store.NewDB(db, opts)
+}
+
+// getBolt returns the bbolt store configured in the config file, using a
+// bucket per namespace.
+func getBolt(config config.Store) (*store.Bolt, error) {
+	db, err := bolt.Open(config.BoltPath(), 0600, &bolt.Options{
+		Timeout: config.BoltTimeout(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := config.Namespace()
+	if bucket == "" {
+		bucket = store.DefaultBoltBucket
+	}
+
+	return store.NewBolt(db, bucket)
+}
+
+// getEtcd returns the etcd store configured in the config file.
+func getEtcd(config config.Etcd) (*store.Etcd, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   config.EtcdEndpoints(),
+		DialTimeout: config.EtcdDialTimeout(),
+		Username:    config.EtcdUsername(),
+		Password:    config.EtcdPassword(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return store.NewEtcd(client, config.EtcdPrefix()), nil
+}
+
+// WithNamespace returns config with the configured namespace replaced by ns,
+// or config unchanged if ns is empty.
+func WithNamespace(config config.Interface, ns string) config.Interface {
+	if ns == "" {
+		return config
+	}
+
+	return namespaceConfig{config, ns}
+}
+
+// namespaceConfig overrides the namespace of the embedded config.
+type namespaceConfig struct {
+	config.Interface
+	namespace string
+}
+
+// Namespace returns the overridden namespace.
+func (c namespaceConfig) Namespace() string {
+	return c.namespace
+}
+
+// blindStore wraps backend with the configured name Blinder, if any.
+func blindStore(config config.Blind, backend store.Interface) (store.Interface, error) {
+	var blinder store.Blinder
+	var err error
+
+	switch config.BlindMode() {
+	case "":
+		return backend, nil
+
+	case "hmac":
+		blinder, err = store.NewHMACBlinder([]byte(config.BlindKey()))
+
+	case "siv":
+		blinder, err = store.NewSIVBlinder([]byte(config.BlindKey()))
+
+	default:
+		err = errors.New("unknown blind mode")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return store.NewBlinded(backend, blinder), nil
+}
+
+// getDSN returns the data source name for the configured database driver.
+//
+// SQLite databases are a local file, so the database name is used as the path
+// to the file.
+func getDSN(config config.DB) string {
+	switch config.DBDriver() {
+	case "postgres":
+		u := &url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(config.DBUsername(), config.DBPassword()),
+			Host:   config.DBHost(),
+			Path:   "/" + config.DBName(),
+		}
+
+		return u.String()
+
+	case "sqlite3":
+		return config.DBName()
+
+	default:
+		return fmt.Sprintf("%s:%s@tcp(%s)/%s",
+			config.DBUsername(),
+			config.DBPassword(),
+			config.DBHost(),
+			config.DBName(),
+		)
+	}
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/domodwyer/cryptic/cmd/shared"
+	"github.com/domodwyer/cryptic/config"
+	"github.com/domodwyer/cryptic/store"
+)
+
+var name = flag.String("name", "", "secret name")
+var prune = flag.Int("prune", 0, "remove all but this many of the most recent versions")
+var namespace = flag.String("namespace", "", "secret namespace (overrides the config file)")
+
+func init() {
+	flag.Parse()
+}
+
+func main() {
+	if *name == "" {
+		log.Print("required parameter missing")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	config := shared.WithNamespace(config.New(), *namespace)
+
+	backend, err := shared.GetStore(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Versions are never decrypted, so there's no encryptor to wrap
+	backend, _, err = shared.WithAudit(config, backend, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	v, ok := backend.(store.Versioner)
+	if !ok {
+		log.Fatal(store.ErrVersioningUnsupported)
+	}
+
+	if *prune > 0 {
+		removed, err := v.Prune(*name, *prune)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("OK (removed %d versions)", removed)
+	}
+
+	versions, err := v.Versions(*name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, version := range versions {
+		fmt.Println(version)
+	}
+}
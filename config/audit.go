@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Audit defines config getters for audit logging.
+type Audit interface {
+	AuditSink() string
+	AuditFile() string
+	AuditActor() string
+	AuditSyslogTag() string
+}
+
+// AuditSink returns the configured audit sink, or an empty string if audit
+// logging is disabled.
+func (v viperStore) AuditSink() string {
+	return strings.ToLower(viper.GetString("Audit.Sink"))
+}
+
+// AuditFile returns the path of the audit log when using the "file" sink.
+func (v viperStore) AuditFile() string {
+	return viper.GetString("Audit.File")
+}
+
+// AuditActor returns the name recorded as performing each operation, or an
+// empty string to use the current user.
+func (v viperStore) AuditActor() string {
+	return viper.GetString("Audit.Actor")
+}
+
+// AuditSyslogTag returns the tag used for syslog messages.
+func (v viperStore) AuditSyslogTag() string {
+	return viper.GetString("Audit.SyslogTag")
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Blind defines config getters for the secret name blinding parameters.
+type Blind interface {
+	BlindMode() string
+	BlindKey() string
+}
+
+// BlindMode returns the configured name blinding mode, or an empty string if
+// names are not blinded.
+func (v viperStore) BlindMode() string {
+	return strings.ToLower(viper.GetString("Blind.Mode"))
+}
+
+// BlindKey returns the configured key used to blind secret names.
+func (v viperStore) BlindKey() string {
+	return viper.GetString("Blind.Key")
+}
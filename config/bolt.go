@@ -0,0 +1,24 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Bolt defines config getters for the bbolt Store parameters.
+type Bolt interface {
+	BoltPath() string
+	BoltTimeout() time.Duration
+}
+
+// BoltPath returns the configured path to the bbolt database file.
+func (v viperStore) BoltPath() string {
+	return viper.GetString("Bolt.Path")
+}
+
+// BoltTimeout returns how long to wait for another process to release the
+// database file lock.
+func (v viperStore) BoltTimeout() time.Duration {
+	return viper.GetDuration("Bolt.Timeout")
+}
@@ -0,0 +1,14 @@
+package config
+
+import "github.com/spf13/viper"
+
+// Cascade defines config getters for the cascade Encryptor parameters.
+type Cascade interface {
+	CascadeLayers() []string
+}
+
+// CascadeLayers returns the configured Encryptor type names, in the order
+// secrets are encrypted with them.
+func (v viperStore) CascadeLayers() []string {
+	return viper.GetStringSlice("Cascade")
+}
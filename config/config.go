@@ -7,10 +7,12 @@ import (
 	"github.com/spf13/viper"
 )
 
-// Interface combines the Store and Encryptor interfaces
+// Interface combines the Store, Encryptor, Audit and Metadata interfaces
 type Interface interface {
 	Store
 	Encryptor
+	Audit
+	Metadata
 }
 
 // Store defines the interface providing getters related to stores
@@ -18,6 +20,13 @@ type Store interface {
 	SelectedStore
 	Redis
 	DB
+	File
+	Bolt
+	S3
+	DynamoDB
+	Etcd
+	Blind
+	Namespace
 }
 
 // Encryptor defines the interface providing getters related to encryptors
@@ -26,6 +35,7 @@ type Encryptor interface {
 	KMS
 	AES
 	KDF
+	Cascade
 }
 
 type viperStore struct{}
@@ -37,15 +47,30 @@ func init() {
 	defaults := map[string]interface{}{
 		"Store":     "redis",
 		"Encryptor": "kms",
+		"Namespace": "",
 
 		// KMS config
 		"KMS.KeyID":  "",
 		"KMS.Region": "eu-west-1",
 
+		// Cascade config
+		"Cascade": []string{},
+
 		// AES config
 		"AES.Key":     "",
 		"AES.HmacKey": "",
 
+		// KDF key source config
+		"AES.KeySource":      "config",
+		"AES.KeyFD":          3,
+		"AES.KeyringService": "cryptic",
+		"AES.KeyringUser":    "default",
+		"AES.KeyCacheSize":   0,
+
+		// Passphrase agent config
+		"Agent.Socket":   "",
+		"Agent.CacheTTL": "15m",
+
 		// Redis store config
 		"Redis.Host":         "127.0.0.1:6379",
 		"Redis.DbIndex":      0,
@@ -55,13 +80,59 @@ func init() {
 		"Redis.MaxRetries":   0,
 
 		// DB store config
-		"DB.Host":        "127.0.0.1:3306",
-		"DB.Username":    "root",
-		"DB.Password":    "",
-		"DB.Name":        "cryptic",
-		"DB.Table":       "secrets",
-		"DB.KeyColumn":   "name",
-		"DB.ValueColumn": "data",
+		"DB.Driver":         "mysql",
+		"DB.Host":           "127.0.0.1:3306",
+		"DB.Username":       "root",
+		"DB.Password":       "",
+		"DB.Name":           "cryptic",
+		"DB.Table":          "secrets",
+		"DB.KeyColumn":      "name",
+		"DB.ValueColumn":    "data",
+		"DB.VersionTable":   "",
+		"DB.ExpiresColumn":  "",
+		"DB.MetadataColumn": "",
+		"DB.Migrate":        false,
+
+		// File store config
+		"File.Path": "/var/lib/cryptic",
+
+		// Bolt store config
+		"Bolt.Path":    "/var/lib/cryptic/cryptic.db",
+		"Bolt.Timeout": "5s",
+
+		// S3 store config
+		"S3.Bucket":               "",
+		"S3.Region":               "eu-west-1",
+		"S3.Prefix":               "",
+		"S3.Endpoint":             "",
+		"S3.ServerSideEncryption": "",
+		"S3.KMSKeyID":             "",
+
+		// DynamoDB store config
+		"DynamoDB.Table":     "cryptic",
+		"DynamoDB.Region":    "eu-west-1",
+		"DynamoDB.Endpoint":  "",
+		"DynamoDB.Credstash": false,
+
+		// Etcd store config
+		"Etcd.Endpoints":   []string{"127.0.0.1:2379"},
+		"Etcd.Prefix":      "cryptic/",
+		"Etcd.DialTimeout": "5s",
+		"Etcd.Username":    "",
+		"Etcd.Password":    "",
+
+		// Name blinding config
+		"Blind.Mode": "",
+		"Blind.Key":  "",
+
+		// Audit logging config
+		"Audit.Sink":      "",
+		"Audit.File":      "/var/log/cryptic/audit.log",
+		"Audit.Actor":     "",
+		"Audit.SyslogTag": "cryptic",
+
+		// Secret metadata config
+		"Metadata.Key": "",
 	}
 
 	// First match takes preference
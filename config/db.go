@@ -1,9 +1,14 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
 
 // DB defines config getters for the database Store parameters.
 type DB interface {
+	DBDriver() string
 	DBHost() string
 	DBName() string
 	DBTable() string
@@ -11,6 +16,16 @@ type DB interface {
 	DBPassword() string
 	DBKeyColumn() string
 	DBValueColumn() string
+	DBVersionTable() string
+	DBExpiresColumn() string
+	DBMetadataColumn() string
+	DBMigrate() bool
+}
+
+// DBDriver returns the configured database driver - one of "mysql", "postgres"
+// or "sqlite3".
+func (v viperStore) DBDriver() string {
+	return strings.ToLower(viper.GetString("DB.Driver"))
 }
 
 // DBHost returns the configured database host (in the form of ip:port).
@@ -47,3 +62,27 @@ func (v viperStore) DBKeyColumn() string {
 func (v viperStore) DBValueColumn() string {
 	return viper.GetString("DB.ValueColumn")
 }
+
+// DBExpiresColumn returns the configured secret expiry column name, or an empty
+// string if expiring secrets is disabled.
+func (v viperStore) DBExpiresColumn() string {
+	return viper.GetString("DB.ExpiresColumn")
+}
+
+// DBMetadataColumn returns the configured secret metadata column name, or an
+// empty string if metadata is disabled.
+func (v viperStore) DBMetadataColumn() string {
+	return viper.GetString("DB.MetadataColumn")
+}
+
+// DBVersionTable returns the configured secret history table name, or an empty
+// string if versioning is disabled.
+func (v viperStore) DBVersionTable() string {
+	return viper.GetString("DB.VersionTable")
+}
+
+// DBMigrate returns true if the database schema should be created or updated
+// each time the store is used.
+func (v viperStore) DBMigrate() bool {
+	return viper.GetBool("DB.Migrate")
+}
@@ -0,0 +1,33 @@
+package config
+
+import "github.com/spf13/viper"
+
+// DynamoDB defines config getters for the DynamoDB Store parameters.
+type DynamoDB interface {
+	DynamoDBTable() string
+	DynamoDBRegion() string
+	DynamoDBEndpoint() string
+	DynamoDBCredstash() bool
+}
+
+// DynamoDBTable returns the configured table secrets are stored in.
+func (v viperStore) DynamoDBTable() string {
+	return viper.GetString("DynamoDB.Table")
+}
+
+// DynamoDBRegion returns the configured AWS region of the table.
+func (v viperStore) DynamoDBRegion() string {
+	return viper.GetString("DynamoDB.Region")
+}
+
+// DynamoDBEndpoint returns the configured URL of a DynamoDB compatible
+// service (such as DynamoDB Local), or an empty string to use Amazon DynamoDB.
+func (v viperStore) DynamoDBEndpoint() string {
+	return viper.GetString("DynamoDB.Endpoint")
+}
+
+// DynamoDBCredstash returns true if the table uses credstash's name/version
+// layout.
+func (v viperStore) DynamoDBCredstash() bool {
+	return viper.GetBool("DynamoDB.Credstash")
+}
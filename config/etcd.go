@@ -0,0 +1,42 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Etcd defines config getters for the etcd Store parameters.
+type Etcd interface {
+	EtcdEndpoints() []string
+	EtcdPrefix() string
+	EtcdDialTimeout() time.Duration
+	EtcdUsername() string
+	EtcdPassword() string
+}
+
+// EtcdEndpoints returns the configured addresses of the etcd cluster members.
+func (v viperStore) EtcdEndpoints() []string {
+	return viper.GetStringSlice("Etcd.Endpoints")
+}
+
+// EtcdPrefix returns the configured prefix of each etcd key.
+func (v viperStore) EtcdPrefix() string {
+	return viper.GetString("Etcd.Prefix")
+}
+
+// EtcdDialTimeout returns how long to wait when connecting to the cluster.
+func (v viperStore) EtcdDialTimeout() time.Duration {
+	return viper.GetDuration("Etcd.DialTimeout")
+}
+
+// EtcdUsername returns the configured etcd username, or an empty string if
+// authentication is disabled.
+func (v viperStore) EtcdUsername() string {
+	return viper.GetString("Etcd.Username")
+}
+
+// EtcdPassword returns the configured etcd password.
+func (v viperStore) EtcdPassword() string {
+	return viper.GetString("Etcd.Password")
+}
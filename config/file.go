@@ -0,0 +1,13 @@
+package config
+
+import "github.com/spf13/viper"
+
+// File defines config getters for the filesystem Store parameters.
+type File interface {
+	FilePath() string
+}
+
+// FilePath returns the configured directory secrets are stored in.
+func (v viperStore) FilePath() string {
+	return viper.GetString("File.Path")
+}
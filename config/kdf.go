@@ -1,13 +1,67 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 // KDF defines the configuration options for PBKDF2 support
 type KDF interface {
 	KDFKey() string
+	KDFKeySource() string
+	KDFKeyFD() int
+	KDFKeyringService() string
+	KDFKeyringUser() string
+	KDFCacheSize() int
+	AgentSocket() string
+	AgentCacheTTL() time.Duration
 }
 
 // KDFKey returns the configured KDF key.
 func (v viperStore) KDFKey() string {
 	return viper.GetString("AES.Key")
 }
+
+// KDFKeySource returns the configured source of the KDF key - one of "config",
+// "prompt", "fd" or "keyring".
+func (v viperStore) KDFKeySource() string {
+	return strings.ToLower(viper.GetString("AES.KeySource"))
+}
+
+// KDFKeyFD returns the configured file descriptor to read the KDF key from when
+// using the "fd" key source.
+func (v viperStore) KDFKeyFD() int {
+	return viper.GetInt("AES.KeyFD")
+}
+
+// KDFKeyringService returns the configured OS keyring service name used to
+// fetch the KDF key when using the "keyring" key source.
+func (v viperStore) KDFKeyringService() string {
+	return viper.GetString("AES.KeyringService")
+}
+
+// KDFKeyringUser returns the configured OS keyring user name used to fetch the
+// KDF key when using the "keyring" key source.
+func (v viperStore) KDFKeyringUser() string {
+	return viper.GetString("AES.KeyringUser")
+}
+
+// KDFCacheSize returns the configured maximum number of derived keys to cache
+// when decrypting, or 0 to disable the cache.
+func (v viperStore) KDFCacheSize() int {
+	return viper.GetInt("AES.KeyCacheSize")
+}
+
+// AgentSocket returns the configured path of the passphrase caching agent
+// socket, or an empty string if no agent is used.
+func (v viperStore) AgentSocket() string {
+	return viper.GetString("Agent.Socket")
+}
+
+// AgentCacheTTL returns the configured duration the passphrase caching agent
+// should hold a prompted passphrase for.
+func (v viperStore) AgentCacheTTL() time.Duration {
+	return viper.GetDuration("Agent.CacheTTL")
+}
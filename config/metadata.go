@@ -0,0 +1,16 @@
+package config
+
+import "github.com/spf13/viper"
+
+// Metadata defines config getters for secret metadata. The namespace is part of
+// the signed metadata.
+type Metadata interface {
+	MetadataKey() string
+	Namespace
+}
+
+// MetadataKey returns the key used to authenticate secret metadata, or an empty
+// string if metadata is disabled.
+func (v viperStore) MetadataKey() string {
+	return viper.GetString("Metadata.Key")
+}
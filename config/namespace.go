@@ -0,0 +1,14 @@
+package config
+
+import "github.com/spf13/viper"
+
+// Namespace defines config getters for the store namespace.
+type Namespace interface {
+	Namespace() string
+}
+
+// Namespace returns the configured namespace secret names are prefixed with,
+// or an empty string if no namespace is used.
+func (v viperStore) Namespace() string {
+	return viper.GetString("Namespace")
+}
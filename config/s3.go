@@ -0,0 +1,46 @@
+package config
+
+import "github.com/spf13/viper"
+
+// S3 defines config getters for the S3 Store parameters.
+type S3 interface {
+	S3Bucket() string
+	S3Region() string
+	S3Prefix() string
+	S3Endpoint() string
+	S3ServerSideEncryption() string
+	S3KMSKeyID() string
+}
+
+// S3Bucket returns the configured bucket secrets are stored in.
+func (v viperStore) S3Bucket() string {
+	return viper.GetString("S3.Bucket")
+}
+
+// S3Region returns the configured AWS region of the bucket.
+func (v viperStore) S3Region() string {
+	return viper.GetString("S3.Region")
+}
+
+// S3Prefix returns the configured prefix of each object key.
+func (v viperStore) S3Prefix() string {
+	return viper.GetString("S3.Prefix")
+}
+
+// S3Endpoint returns the configured URL of an S3 compatible service, or an
+// empty string to use Amazon S3.
+func (v viperStore) S3Endpoint() string {
+	return viper.GetString("S3.Endpoint")
+}
+
+// S3ServerSideEncryption returns the configured server-side encryption mode -
+// "AES256", "aws:kms", or an empty string to use the bucket default.
+func (v viperStore) S3ServerSideEncryption() string {
+	return viper.GetString("S3.ServerSideEncryption")
+}
+
+// S3KMSKeyID returns the configured KMS key used for "aws:kms" server-side
+// encryption.
+func (v viperStore) S3KMSKeyID() string {
+	return viper.GetString("S3.KMSKeyID")
+}
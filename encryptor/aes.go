@@ -92,8 +92,11 @@ func (e *AESCTREncryptor) Decrypt(data *EncryptedData) ([]byte, error) {
 	iv := data.Ciphertext[:aes.BlockSize]
 	buf := data.Ciphertext[aes.BlockSize:]
 
+	// Decrypt into a new slice so data can be decrypted again
+	plain := make([]byte, len(buf))
+
 	stream := cipher.NewCTR(e.block, iv)
-	stream.XORKeyStream(buf, buf)
+	stream.XORKeyStream(plain, buf)
 
-	return buf, nil
+	return plain, nil
 }
@@ -118,6 +118,17 @@ func TestAESCTREncryptorIntegration(t *testing.T) {
 		if !bytes.Equal(got, tt.want) {
 			t.Errorf("%q. Secret mismatch, got %v, want %v", tt.name, string(got), string(tt.want))
 		}
+
+		// Decrypting must not modify the input
+		got, err = e.Decrypt(encrypted)
+		if err != nil {
+			t.Errorf("%q. second Decrypt() = %s", tt.name, err)
+			continue
+		}
+
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%q. Secret mismatch on second Decrypt(), got %v, want %v", tt.name, string(got), string(tt.want))
+		}
 	}
 }
 
@@ -0,0 +1,117 @@
+package encryptor
+
+import "context"
+
+// Cascade encrypts secrets with each of Layers in turn, so the secret cannot be
+// recovered without compromising every layer - for example, encrypting with a
+// local AES key and then wrapping the result with KMS means neither the AWS
+// account or the local configuration alone is enough to decrypt the secret.
+//
+// The Type, HMAC and Context of each layer is stored in the Context of the
+// result, and Decrypt unwinds the layers in reverse order.
+type Cascade struct {
+	Layers []EncryptDecryptor
+}
+
+type cascadeLayer struct {
+	Type    uint8
+	HMAC    []byte
+	Context map[string]interface{}
+}
+
+// NewCascade returns an initialised Cascade, encrypting with each of layers in
+// the order given.
+func NewCascade(layers ...EncryptDecryptor) (*Cascade, error) {
+	if len(layers) < 1 {
+		return nil, ErrNoLayers
+	}
+
+	return &Cascade{Layers: layers}, nil
+}
+
+// Encrypt passes the secret to the first layer, and the resulting cipher-text
+// to each subsequent layer.
+func (e *Cascade) Encrypt(secret []byte) (*EncryptedData, error) {
+	return e.EncryptContext(context.Background(), secret)
+}
+
+// EncryptContext is the same as Encrypt, passing ctx to any layers that support
+// it.
+func (e *Cascade) EncryptContext(ctx context.Context, secret []byte) (*EncryptedData, error) {
+	if len(e.Layers) < 1 {
+		return nil, ErrNoLayers
+	}
+
+	layers := make([]cascadeLayer, 0, len(e.Layers))
+
+	input := secret
+	for _, enc := range e.Layers {
+		data, err := WithContext(enc).EncryptContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, cascadeLayer{
+			Type:    data.Type,
+			HMAC:    data.HMAC,
+			Context: data.Context,
+		})
+
+		input = data.Ciphertext
+	}
+
+	return &EncryptedData{
+		Ciphertext: input,
+		Type:       Cascaded,
+		Context: map[string]interface{}{
+			"cascade": layers,
+		},
+	}, nil
+}
+
+// Decrypt passes data to the last layer, and the resulting plain-text to each
+// preceding layer, returning the original secret.
+func (e *Cascade) Decrypt(data *EncryptedData) ([]byte, error) {
+	return e.DecryptContext(context.Background(), data)
+}
+
+// DecryptContext is the same as Decrypt, passing ctx to any layers that support
+// it.
+func (e *Cascade) DecryptContext(ctx context.Context, data *EncryptedData) ([]byte, error) {
+	// Ensure this data was encrypted by a Cascade
+	if data.Type != Cascaded {
+		return []byte{}, ErrWrongType
+	}
+
+	ctxInt, ok := data.Context["cascade"]
+	if !ok {
+		return []byte{}, ErrMissingContext
+	}
+
+	layers, ok := ctxInt.([]cascadeLayer)
+	if !ok {
+		return []byte{}, ErrMissingContext
+	}
+
+	// The layers must match those used to encrypt
+	if len(layers) != len(e.Layers) {
+		return []byte{}, ErrMissingContext
+	}
+
+	input := data.Ciphertext
+	for i := len(layers) - 1; i >= 0; i-- {
+		plain, err := WithContext(e.Layers[i]).DecryptContext(ctx, &EncryptedData{
+			Ciphertext: input,
+			HMAC:       layers[i].HMAC,
+			Type:       layers[i].Type,
+			Context:    layers[i].Context,
+		})
+		if err != nil {
+			return []byte{}, err
+		}
+
+		input = plain
+	}
+
+	return input, nil
+}
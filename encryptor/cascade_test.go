@@ -0,0 +1,193 @@
+package encryptor
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestCascadeIntegration ensures secrets survive a round trip through multiple
+// layers, including being marshalled for storage.
+func TestCascadeIntegration(t *testing.T) {
+	gcm, _ := NewAESGCM([]byte("anAesTestKey1234"))
+	ctr, _ := NewAES([]byte("anotherAesKey123"), []byte("hmac"))
+	kdf, _ := NewKDF([]byte("smallkey!"))
+	kdf.Iterations = 32 // small for testing
+
+	kms := &KMS{
+		svc:     &mockKms{keyID: "keyId"},
+		keyID:   "keyId",
+		KeySize: 64,
+		Provider: func(key []byte) (EncryptDecryptor, error) {
+			return NewAES(key[:32], key[32:])
+		},
+	}
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		layers []EncryptDecryptor
+		secret []byte
+	}{
+		{
+			"Single layer",
+			[]EncryptDecryptor{gcm},
+			[]byte("i am a secret"),
+		},
+		{
+			"AES-GCM then KMS",
+			[]EncryptDecryptor{gcm, kms},
+			[]byte("i am a secret"),
+		},
+		{
+			"Three layers",
+			[]EncryptDecryptor{ctr, kdf, gcm},
+			[]byte{0xb0, 0x75, 0x11, 0x62, 0x00, 0x1d},
+		},
+	}
+
+	for _, tt := range tests {
+		e, err := NewCascade(tt.layers...)
+		if err != nil {
+			t.Errorf("%q. NewCascade() error = %v", tt.name, err)
+			continue
+		}
+
+		encrypted, err := e.Encrypt(tt.secret)
+		if err != nil {
+			t.Errorf("%q. Cascade.Encrypt() error = %v", tt.name, err)
+			continue
+		}
+
+		if encrypted.Type != Cascaded {
+			t.Errorf("%q. Cascade.Encrypt() type = %v, want %v", tt.name, encrypted.Type, Cascaded)
+		}
+
+		buf, err := encrypted.MarshalBinary()
+		if err != nil {
+			t.Errorf("%q. MarshalBinary() error = %v", tt.name, err)
+			continue
+		}
+
+		stored := &EncryptedData{}
+		if err := stored.UnmarshalBinary(buf); err != nil {
+			t.Errorf("%q. UnmarshalBinary() error = %v", tt.name, err)
+			continue
+		}
+
+		got, err := e.Decrypt(stored)
+		if err != nil {
+			t.Errorf("%q. Cascade.Decrypt() error = %v", tt.name, err)
+			continue
+		}
+
+		if !bytes.Equal(got, tt.secret) {
+			t.Errorf("%q. Cascade.Decrypt() = %v, want %v", tt.name, got, tt.secret)
+		}
+	}
+}
+
+func TestCascadeEncrypt(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Receiver fields.
+		rLayers []EncryptDecryptor
+		// Expected results.
+		wantErr error
+	}{
+		{
+			"Known good",
+			[]EncryptDecryptor{NopEncryptor{}, NopEncryptor{}},
+			nil,
+		},
+		{
+			"No layers",
+			[]EncryptDecryptor{},
+			ErrNoLayers,
+		},
+		{
+			"Layer Encrypt() errors passed up",
+			[]EncryptDecryptor{NopEncryptor{}, &errEncryptor{errMarker}},
+			errMarker,
+		},
+	}
+
+	for _, tt := range tests {
+		e := &Cascade{Layers: tt.rLayers}
+
+		if _, err := e.Encrypt([]byte("secret")); err != tt.wantErr {
+			t.Errorf("%q. Cascade.Encrypt() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCascadeDecrypt(t *testing.T) {
+	gcm, _ := NewAESGCM([]byte("anAesTestKey1234"))
+	other, _ := NewAESGCM([]byte("anotherAesKey123"))
+
+	e, _ := NewCascade(NopEncryptor{}, gcm)
+	good, _ := e.Encrypt([]byte("secret"))
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Receiver fields.
+		rLayers []EncryptDecryptor
+		// Parameters.
+		data *EncryptedData
+		// Expected results.
+		wantErr error
+	}{
+		{
+			"Known good",
+			[]EncryptDecryptor{NopEncryptor{}, gcm},
+			good,
+			nil,
+		},
+		{
+			"Wrong type",
+			[]EncryptDecryptor{NopEncryptor{}, gcm},
+			&EncryptedData{Type: AESGCM, Context: good.Context},
+			ErrWrongType,
+		},
+		{
+			"Missing context",
+			[]EncryptDecryptor{NopEncryptor{}, gcm},
+			&EncryptedData{Type: Cascaded, Context: map[string]interface{}{}},
+			ErrMissingContext,
+		},
+		{
+			"Wrong context type",
+			[]EncryptDecryptor{NopEncryptor{}, gcm},
+			&EncryptedData{Type: Cascaded, Context: map[string]interface{}{"cascade": "wrong"}},
+			ErrMissingContext,
+		},
+		{
+			"Layer count mismatch",
+			[]EncryptDecryptor{gcm},
+			good,
+			ErrMissingContext,
+		},
+		{
+			"Layer Decrypt() errors passed up",
+			[]EncryptDecryptor{NopEncryptor{}, &errEncryptor{errMarker}},
+			good,
+			errMarker,
+		},
+	}
+
+	for _, tt := range tests {
+		e := &Cascade{Layers: tt.rLayers}
+
+		if _, err := e.Decrypt(tt.data); err != tt.wantErr {
+			t.Errorf("%q. Cascade.Decrypt() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// Every layer must be able to decrypt
+	e = &Cascade{Layers: []EncryptDecryptor{NopEncryptor{}, other}}
+	if _, err := e.Decrypt(good); err == nil {
+		t.Errorf("Wrong layer key. Cascade.Decrypt() error = nil, want error")
+	}
+}
@@ -0,0 +1,40 @@
+package encryptor
+
+import "context"
+
+// WithContext returns e as a ContextEncryptDecryptor.
+//
+// If e does not implement ContextEncryptDecryptor, ctx is checked before each
+// call is passed to e - encryptors that do not make network calls complete
+// quickly, so there is nothing to cancel once they have started.
+func WithContext(e EncryptDecryptor) ContextEncryptDecryptor {
+	if c, ok := e.(ContextEncryptDecryptor); ok {
+		return c
+	}
+
+	return contextAdapter{e}
+}
+
+// contextAdapter implements ContextEncryptDecryptor for an encryptor that
+// doesn't support contexts.
+type contextAdapter struct {
+	e EncryptDecryptor
+}
+
+// EncryptContext calls Encrypt, unless ctx is already cancelled.
+func (a contextAdapter) EncryptContext(ctx context.Context, secret []byte) (*EncryptedData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return a.e.Encrypt(secret)
+}
+
+// DecryptContext calls Decrypt, unless ctx is already cancelled.
+func (a contextAdapter) DecryptContext(ctx context.Context, data *EncryptedData) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return []byte{}, err
+	}
+
+	return a.e.Decrypt(data)
+}
@@ -0,0 +1,103 @@
+package encryptor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestWithContext ensures context-aware encryptors receive the context, and
+// the adapter refuses to start once the context is cancelled.
+func TestWithContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	kms := &KMS{
+		svc:     &mockKms{keyID: "keyId"},
+		keyID:   "keyId",
+		KeySize: 64,
+		Provider: func(key []byte) (EncryptDecryptor, error) {
+			return NopEncryptor{}, nil
+		},
+	}
+
+	direct := &KMSDirectEncryptor{KMS: kms, MaxSize: kmsDirectMaxSize}
+	cascade, _ := NewCascade(NopEncryptor{}, kms)
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		enc EncryptDecryptor
+		ctx context.Context
+		// Expected results.
+		wantErr error
+	}{
+		{
+			"Adapter",
+			NopEncryptor{},
+			context.Background(),
+			nil,
+		},
+		{
+			"Adapter cancelled",
+			NopEncryptor{},
+			cancelled,
+			context.Canceled,
+		},
+		{
+			"KMS",
+			kms,
+			context.Background(),
+			nil,
+		},
+		{
+			"KMS cancelled",
+			kms,
+			cancelled,
+			context.Canceled,
+		},
+		{
+			"KMS direct cancelled",
+			direct,
+			cancelled,
+			context.Canceled,
+		},
+		{
+			"Cascade cancelled",
+			cascade,
+			cancelled,
+			context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		e := WithContext(tt.enc)
+
+		data, err := e.EncryptContext(tt.ctx, []byte("secret"))
+		if err != tt.wantErr {
+			t.Errorf("%q. EncryptContext() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if err != nil {
+			// Ensure decryption is cancelled too
+			data, _ = tt.enc.Encrypt([]byte("secret"))
+			if _, err := e.DecryptContext(tt.ctx, data); err != tt.wantErr {
+				t.Errorf("%q. DecryptContext() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+
+			continue
+		}
+
+		got, err := e.DecryptContext(tt.ctx, data)
+		if err != nil {
+			t.Errorf("%q. DecryptContext() error = %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, []byte("secret")) {
+			t.Errorf("%q. DecryptContext() = %v, want %v", tt.name, got, []byte("secret"))
+		}
+	}
+}
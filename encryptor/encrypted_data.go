@@ -21,6 +21,7 @@ type EncryptedData struct {
 
 func init() {
 	gob.Register(kdfParameters{})
+	gob.Register([]cascadeLayer{})
 }
 
 // MarshalBinary returns the EncryptedData struct encoded into a slice of bytes
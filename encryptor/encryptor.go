@@ -1,5 +1,7 @@
 package encryptor
 
+import "context"
+
 // Used to identify different Encryptor types
 const (
 	Nop uint8 = iota
@@ -7,6 +9,9 @@ const (
 	KMSWrapped
 	Pbkdf2
 	AESGCM
+	KMSDirect
+	AESGCMCommit
+	Cascaded
 )
 
 // Encryptor defines the Encrypt method, used to encrypt the given plain-text.
@@ -25,6 +30,25 @@ type EncryptDecryptor interface {
 	Decryptor
 }
 
+// ContextEncryptor defines the EncryptContext method, used to encrypt the
+// given plain-text, abandoning the operation if ctx is cancelled.
+type ContextEncryptor interface {
+	EncryptContext(ctx context.Context, secret []byte) (*EncryptedData, error)
+}
+
+// ContextDecryptor defines the DecryptContext method, used to decrypt the
+// given cipher-text, abandoning the operation if ctx is cancelled.
+type ContextDecryptor interface {
+	DecryptContext(ctx context.Context, data *EncryptedData) ([]byte, error)
+}
+
+// ContextEncryptDecryptor defines the context-aware methods used by our
+// encryptor structs.
+type ContextEncryptDecryptor interface {
+	ContextEncryptor
+	ContextDecryptor
+}
+
 // EncryptionProvider implementers should return an initalised Encryptor where
 // key is the key material for initalisation.
 type EncryptionProvider func(key []byte) (EncryptDecryptor, error)
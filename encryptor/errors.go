@@ -22,4 +22,7 @@ var (
 
 	// ErrMissingContext indicates required contextual data is missing.
 	ErrMissingContext = errors.New("encryptor: missing required context data")
+
+	// ErrNoLayers indicates a Cascade has no layers to encrypt with.
+	ErrNoLayers = errors.New("encryptor: no cascade layers configured")
 )
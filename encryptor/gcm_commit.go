@@ -0,0 +1,116 @@
+package encryptor
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+)
+
+// AESGCMCommitEncryptor provides AES-GCM encryption of secrets with an
+// additional key commitment, ensuring a ciphertext can only be decrypted by the
+// key that created it.
+//
+// AES-GCM alone is not key-committing - it is possible to craft a ciphertext
+// that decrypts successfully under two different keys, which matters when the
+// key is chosen from several candidates (keyrings, password derived keys,
+// etc). AESGCMCommitEncryptor derives the AES key from the provided key using
+// HMAC-SHA256, and stores a commitment to the key (a HMAC of the nonce) in the
+// HMAC field that is verified before decryption.
+type AESGCMCommitEncryptor struct {
+	gcm       cipher.AEAD
+	commitKey []byte
+}
+
+// NewAESGCMCommit returns an initalised Encryptor using AES with GCM (Galois
+// Counter Mode) and a key commitment.
+//
+// aesKey must be 16, 24 or 32 bytes long, and the derived AES key is the same
+// length.
+func NewAESGCMCommit(aesKey []byte) (*AESGCMCommitEncryptor, error) {
+	// Ensure the key is a valid AES key size
+	if _, err := aes.NewCipher(aesKey); err != nil {
+		return nil, ErrKeyTooShort
+	}
+
+	encKey := commitmentMAC(aesKey, []byte("cryptic-gcm-enc"))[:len(aesKey)]
+
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, ErrKeyTooShort
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		// This will never happen, AES uses 128-bit blocks
+		return nil, err
+	}
+
+	return &AESGCMCommitEncryptor{
+		gcm:       gcm,
+		commitKey: aesKey,
+	}, nil
+}
+
+// Encrypt generates a unique nonce for each encryption, encrypts the plain-text
+// secret with the derived AES key and generates the key commitment.
+func (e *AESGCMCommitEncryptor) Encrypt(plaintext []byte) (*EncryptedData, error) {
+	// Generate a random nonce
+	nonce := make([]byte, e.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		// No entropy? You've got bigger problems
+		return nil, err
+	}
+
+	// Encrypt, appending the ciphertext to the nonce slice
+	return &EncryptedData{
+		Ciphertext: e.gcm.Seal(nonce, nonce, plaintext, nil),
+		HMAC:       e.commitment(nonce),
+		Type:       AESGCMCommit,
+	}, nil
+}
+
+// Decrypt ensures data was encrypted with AESGCMCommitEncryptor, validates the
+// key commitment in constant time and decrypts the cipher-text (which also
+// ensures data integrity) returning the plain-text.
+func (e *AESGCMCommitEncryptor) Decrypt(data *EncryptedData) ([]byte, error) {
+	// Ensure we're operating on something that AESGCMCommitEncryptor encrypted
+	if data.Type != AESGCMCommit {
+		return nil, ErrWrongType
+	}
+
+	// Ensure our input slice is at least gcm.NonceSize() to avoid an
+	// out-of-bounds access
+	if len(data.Ciphertext) < e.gcm.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce := data.Ciphertext[:e.gcm.NonceSize()]
+
+	// Ensure the ciphertext was created with this key
+	if !hmac.Equal(data.HMAC, e.commitment(nonce)) {
+		return nil, ErrInvalidHmac
+	}
+
+	return e.gcm.Open(
+		nil,
+		nonce,
+		data.Ciphertext[e.gcm.NonceSize():],
+		nil,
+	)
+}
+
+// commitment returns the key commitment for the given nonce.
+func (e *AESGCMCommitEncryptor) commitment(nonce []byte) []byte {
+	return commitmentMAC(e.commitKey, append([]byte("cryptic-gcm-commit"), nonce...))
+}
+
+// commitmentMAC returns the HMAC-SHA256 of data using key.
+func commitmentMAC(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	return mac.Sum(nil)
+}
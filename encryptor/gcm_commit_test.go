@@ -0,0 +1,157 @@
+package encryptor
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestNewAESGCMCommit ensures invalid input returns the correct error types.
+func TestNewAESGCMCommit(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		aesKey []byte
+		// Expected results.
+		wantErr error
+	}{
+		{
+			"Correct",
+			[]byte("12345678901234567890123456789012"),
+			nil,
+		},
+		{
+			"AES key required",
+			[]byte{},
+			ErrKeyTooShort,
+		},
+		{
+			"Error with wrong AES key length",
+			[]byte("short"),
+			ErrKeyTooShort,
+		},
+	}
+	for _, tt := range tests {
+		_, err := NewAESGCMCommit(tt.aesKey)
+
+		if err != tt.wantErr {
+			t.Errorf("%q. NewAESGCMCommit() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestAESGCMCommitEncryptorIntegration ensures Encrypt() and Decrypt() work
+// together to produce the same plain-text as the original input.
+func TestAESGCMCommitEncryptorIntegration(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		want []byte
+	}{
+		{
+			"Simple string",
+			[]byte("i am a secret"),
+		},
+		{
+			"Binary",
+			[]byte{
+				0xb0, 0x75, 0x11, 0x62, 0xa2, 0x3e, 0x5f, 0x2f,
+				0xca, 0xa3, 0x00, 0x1d, 0x51, 0x89, 0xc8, 0xe7,
+				0xb5, 0x15, 0xb9, 0x5c, 0x9b, 0x3e, 0x26, 0x5f,
+				0xb2, 0x6b, 0x97, 0x41, 0x16, 0x2c, 0x47, 0x10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		e, err := NewAESGCMCommit([]byte("anAesTestKey1234"))
+		if err != nil {
+			t.Errorf("%q. NewAESGCMCommit() = %s", tt.name, err)
+			continue
+		}
+
+		encrypted, err := e.Encrypt(tt.want)
+		if err != nil {
+			t.Errorf("%q. Encrypt() = %s", tt.name, err)
+			continue
+		}
+
+		if encrypted.Type != AESGCMCommit {
+			t.Errorf("%q. Encrypt() type = %v, want %v", tt.name, encrypted.Type, AESGCMCommit)
+		}
+
+		got, err := e.Decrypt(encrypted)
+		if err != nil {
+			t.Errorf("%q. Decrypt() = %s", tt.name, err)
+			continue
+		}
+
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%q. Secret mismatch, got %v, want %v", tt.name, string(got), string(tt.want))
+		}
+	}
+}
+
+// TestAESGCMCommitEncryptorDecrypt ensures data from other keys, other
+// encryptors or with a tampered commitment is rejected.
+func TestAESGCMCommitEncryptorDecrypt(t *testing.T) {
+	e, _ := NewAESGCMCommit([]byte("anAesTestKey1234"))
+	other, _ := NewAESGCMCommit([]byte("anotherTestKey12"))
+	plain, _ := NewAESGCM([]byte("anAesTestKey1234"))
+
+	good, _ := e.Encrypt([]byte("secret"))
+	otherKey, _ := other.Encrypt([]byte("secret"))
+	gcmData, _ := plain.Encrypt([]byte("secret"))
+
+	tampered := *good
+	tampered.HMAC = append([]byte{}, good.HMAC...)
+	tampered.HMAC[0] ^= 0x01
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters
+		data *EncryptedData
+		// Expected results.
+		wantErr error
+	}{
+		{
+			"Known good",
+			good,
+			nil,
+		},
+		{
+			"Different key",
+			otherKey,
+			ErrInvalidHmac,
+		},
+		{
+			"Tampered commitment",
+			&tampered,
+			ErrInvalidHmac,
+		},
+		{
+			"Missing commitment",
+			&EncryptedData{Ciphertext: good.Ciphertext, Type: AESGCMCommit},
+			ErrInvalidHmac,
+		},
+		{
+			"Plain AESGCM",
+			gcmData,
+			ErrWrongType,
+		},
+		{
+			"Ciphertext too short",
+			&EncryptedData{Ciphertext: []byte{0x42}, Type: AESGCMCommit},
+			ErrInvalidCiphertext,
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := e.Decrypt(tt.data)
+		if err != tt.wantErr {
+			t.Errorf("%q. Decrypt() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
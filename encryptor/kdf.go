@@ -13,11 +13,15 @@ const kdfKeySize = 64
 // Provider.
 //
 // By default Provider is AES-512.
+//
+// If Cache is set, keys derived when decrypting are cached and reused when the
+// same secret is decrypted again.
 type KDF struct {
 	Provider   EncryptionProvider
 	SaltSize   int
 	Iterations int
 	SourceKey  []byte
+	Cache      *KeyCache
 }
 
 type kdfParameters struct {
@@ -107,8 +111,15 @@ func (e KDF) Decrypt(data *EncryptedData) ([]byte, error) {
 		return []byte{}, ErrMissingContext
 	}
 
-	// Generate the key
-	key := pbkdf2.Key(e.SourceKey, ctx.Salt, ctx.Iterations, kdfKeySize, sha512.New)
+	// Generate the key, or use a cached copy
+	key, ok := e.cachedKey(ctx.Salt, ctx.Iterations)
+	if !ok {
+		key = pbkdf2.Key(e.SourceKey, ctx.Salt, ctx.Iterations, kdfKeySize, sha512.New)
+
+		if e.Cache != nil {
+			e.Cache.Put(e.SourceKey, ctx.Salt, ctx.Iterations, key)
+		}
+	}
 
 	// Give it to the decryption provider
 	dec, err := e.Provider(key)
@@ -122,3 +133,12 @@ func (e KDF) Decrypt(data *EncryptedData) ([]byte, error) {
 
 	return dec.Decrypt(&mutable)
 }
+
+// cachedKey returns the derived key from Cache, if any.
+func (e KDF) cachedKey(salt []byte, iterations int) ([]byte, bool) {
+	if e.Cache == nil {
+		return nil, false
+	}
+
+	return e.Cache.Get(e.SourceKey, salt, iterations)
+}
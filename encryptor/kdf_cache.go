@@ -0,0 +1,133 @@
+package encryptor
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+)
+
+// KeyCache holds a bounded number of keys derived by KDF, avoiding the cost of
+// re-running PBKDF2 when the same secret is decrypted more than once.
+//
+// Keys are identified by a SHA-256 hash of the source key, salt and iteration
+// count, and the least recently used key is evicted once the cache is full.
+// Evicted keys are overwritten in memory.
+//
+// KeyCache is safe for concurrent use by multiple goroutines.
+type KeyCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type keyCacheEntry struct {
+	id  [sha256.Size]byte
+	key []byte
+}
+
+// NewKeyCache returns an initialised KeyCache holding at most size keys.
+func NewKeyCache(size int) *KeyCache {
+	return &KeyCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[[sha256.Size]byte]*list.Element{},
+	}
+}
+
+// Get returns a copy of the cached key derived from sourceKey with the given
+// salt and iterations, if any.
+func (c *KeyCache) Get(sourceKey, salt []byte, iterations int) ([]byte, bool) {
+	id := keyCacheID(sourceKey, salt, iterations)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+
+	// Return a copy so evicting the key doesn't modify it while in use
+	return append([]byte{}, el.Value.(*keyCacheEntry).key...), true
+}
+
+// Put adds a copy of key, derived from sourceKey with the given salt and
+// iterations, evicting the least recently used key if the cache is full.
+func (c *KeyCache) Put(sourceKey, salt []byte, iterations int, key []byte) {
+	id := keyCacheID(sourceKey, salt, iterations)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size < 1 {
+		return
+	}
+
+	if el, ok := c.entries[id]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+
+	for c.order.Len() >= c.size {
+		c.remove(c.order.Back())
+	}
+
+	c.entries[id] = c.order.PushFront(&keyCacheEntry{
+		id:  id,
+		key: append([]byte{}, key...),
+	})
+}
+
+// Len returns the number of cached keys.
+func (c *KeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Purge removes and overwrites all cached keys.
+func (c *KeyCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.order.Len() > 0 {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove evicts el from the cache, overwriting the key. It must be called with
+// mu held.
+func (c *KeyCache) remove(el *list.Element) {
+	entry := c.order.Remove(el).(*keyCacheEntry)
+	delete(c.entries, entry.id)
+
+	for i := range entry.key {
+		entry.key[i] = 0
+	}
+}
+
+// keyCacheID returns the cache identifier for the given KDF parameters.
+func keyCacheID(sourceKey, salt []byte, iterations int) [sha256.Size]byte {
+	h := sha256.New()
+
+	// Length prefix the variable length inputs so they can't be confused
+	buf := make([]byte, 8)
+	for _, b := range [][]byte{sourceKey, salt} {
+		binary.BigEndian.PutUint64(buf, uint64(len(b)))
+		h.Write(buf)
+		h.Write(b)
+	}
+
+	binary.BigEndian.PutUint64(buf, uint64(iterations))
+	h.Write(buf)
+
+	id := [sha256.Size]byte{}
+	copy(id[:], h.Sum(nil))
+
+	return id
+}
@@ -0,0 +1,219 @@
+package encryptor
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestKeyCache(t *testing.T) {
+	c := NewKeyCache(2)
+
+	c.Put([]byte("source"), []byte("salt1"), 4096, []byte("key1"))
+	c.Put([]byte("source"), []byte("salt2"), 4096, []byte("key2"))
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		sourceKey  []byte
+		salt       []byte
+		iterations int
+		// Expected results.
+		want   []byte
+		wantOk bool
+	}{
+		{
+			"Hit",
+			[]byte("source"),
+			[]byte("salt1"),
+			4096,
+			[]byte("key1"),
+			true,
+		},
+		{
+			"Different salt",
+			[]byte("source"),
+			[]byte("salt3"),
+			4096,
+			nil,
+			false,
+		},
+		{
+			"Different iterations",
+			[]byte("source"),
+			[]byte("salt1"),
+			32,
+			nil,
+			false,
+		},
+		{
+			"Different source key",
+			[]byte("other"),
+			[]byte("salt1"),
+			4096,
+			nil,
+			false,
+		},
+		{
+			"Ambiguous concatenation",
+			[]byte("sourcesalt"),
+			[]byte("1"),
+			4096,
+			nil,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := c.Get(tt.sourceKey, tt.salt, tt.iterations)
+		if ok != tt.wantOk {
+			t.Errorf("%q. KeyCache.Get() ok = %v, want %v", tt.name, ok, tt.wantOk)
+			continue
+		}
+
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%q. KeyCache.Get() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestKeyCacheEviction ensures the least recently used key is evicted and
+// overwritten.
+func TestKeyCacheEviction(t *testing.T) {
+	c := NewKeyCache(2)
+
+	c.Put([]byte("source"), []byte("salt1"), 4096, []byte("key1"))
+	c.Put([]byte("source"), []byte("salt2"), 4096, []byte("key2"))
+
+	// Grab a reference to the cached copy of key2 to check it's wiped
+	el := c.entries[keyCacheID([]byte("source"), []byte("salt2"), 4096)]
+	evicted := el.Value.(*keyCacheEntry).key
+
+	// Use key1, making key2 the least recently used
+	c.Get([]byte("source"), []byte("salt1"), 4096)
+
+	c.Put([]byte("source"), []byte("salt3"), 4096, []byte("key3"))
+
+	if c.Len() != 2 {
+		t.Errorf("KeyCache.Len() = %d, want 2", c.Len())
+	}
+
+	if _, ok := c.Get([]byte("source"), []byte("salt2"), 4096); ok {
+		t.Errorf("KeyCache.Get() returned evicted key")
+	}
+
+	if !bytes.Equal(evicted, make([]byte, len(evicted))) {
+		t.Errorf("KeyCache did not wipe evicted key, got %q", evicted)
+	}
+
+	for _, salt := range []string{"salt1", "salt3"} {
+		if _, ok := c.Get([]byte("source"), []byte(salt), 4096); !ok {
+			t.Errorf("KeyCache.Get() missing key for %s", salt)
+		}
+	}
+
+	c.Purge()
+
+	if c.Len() != 0 {
+		t.Errorf("KeyCache.Purge() left %d keys", c.Len())
+	}
+}
+
+// TestKeyCacheCopies ensures callers cannot modify the cached keys.
+func TestKeyCacheCopies(t *testing.T) {
+	c := NewKeyCache(1)
+
+	key := []byte("key1")
+	c.Put([]byte("source"), []byte("salt1"), 4096, key)
+	key[0] = 'X'
+
+	got, _ := c.Get([]byte("source"), []byte("salt1"), 4096)
+	got[1] = 'X'
+
+	got, _ = c.Get([]byte("source"), []byte("salt1"), 4096)
+	if !bytes.Equal(got, []byte("key1")) {
+		t.Errorf("KeyCache.Get() = %q, want %q", got, "key1")
+	}
+}
+
+// TestKDFDecryptCached ensures KDF populates and uses the cache, and decrypts
+// concurrently.
+func TestKDFDecryptCached(t *testing.T) {
+	e, _ := NewKDF([]byte("smallkey!"))
+	e.Iterations = 32 // small for testing
+	e.Cache = NewKeyCache(16)
+
+	secrets := []*EncryptedData{}
+	for i := 0; i < 8; i++ {
+		data, err := e.Encrypt([]byte(fmt.Sprintf("secret %d", i)))
+		if err != nil {
+			t.Fatalf("KDF.Encrypt() error = %v", err)
+		}
+
+		secrets = append(secrets, data)
+	}
+
+	wg := sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i, data := range secrets {
+				got, err := e.Decrypt(data)
+				if err != nil {
+					t.Errorf("KDF.Decrypt() error = %v", err)
+					return
+				}
+
+				if want := fmt.Sprintf("secret %d", i); string(got) != want {
+					t.Errorf("KDF.Decrypt() = %q, want %q", got, want)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if e.Cache.Len() != len(secrets) {
+		t.Errorf("KeyCache.Len() = %d, want %d", e.Cache.Len(), len(secrets))
+	}
+
+	// Cached keys must not be used for a different source key
+	e.SourceKey = []byte("otherkey!")
+	if _, err := e.Decrypt(secrets[0]); err != ErrInvalidHmac {
+		t.Errorf("KDF.Decrypt() with wrong key error = %v, want %v", err, ErrInvalidHmac)
+	}
+}
+
+// benchmarkKDFDecryptBatch decrypts a batch of secrets per iteration, as a
+// service reloading its secrets would.
+func benchmarkKDFDecryptBatch(b *testing.B, cache *KeyCache) {
+	e, _ := NewKDF([]byte("smallkey!"))
+	e.Cache = cache
+
+	secrets := []*EncryptedData{}
+	for i := 0; i < 20; i++ {
+		data, _ := e.Encrypt([]byte("secret"))
+		secrets = append(secrets, data)
+	}
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		for _, data := range secrets {
+			if _, err := e.Decrypt(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkKDFDecryptBatch(b *testing.B) {
+	benchmarkKDFDecryptBatch(b, nil)
+}
+
+func BenchmarkKDFDecryptBatchCached(b *testing.B) {
+	benchmarkKDFDecryptBatch(b, NewKeyCache(32))
+}
@@ -114,6 +114,43 @@ func TestAESGCMKDFEncryptorIntegration(t *testing.T) {
 	}
 }
 
+// TestAESGCMCommitKDFEncryptorIntegration ensures a key is generated, secret
+// encrypted with key-committing AESGCM, and decrypted to the same plaintext.
+func TestAESGCMCommitKDFEncryptorIntegration(t *testing.T) {
+	e, err := NewKDF([]byte("smallkey!"))
+	if err != nil {
+		t.Fatalf("NewKDF() = %s", err)
+	}
+
+	e.Provider = func(key []byte) (EncryptDecryptor, error) {
+		return NewAESGCMCommit(key[:32])
+	}
+
+	encrypted, err := e.Encrypt([]byte("i am a secret"))
+	if err != nil {
+		t.Fatalf("Encrypt() = %s", err)
+	}
+
+	if p := encrypted.Context["kdf"].(kdfParameters); p.OrigType != AESGCMCommit {
+		t.Errorf("Encrypt() original type = %v, want %v", p.OrigType, AESGCMCommit)
+	}
+
+	got, err := e.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() = %s", err)
+	}
+
+	if !bytes.Equal(got, []byte("i am a secret")) {
+		t.Errorf("Secret mismatch, got %v, want %v", got, []byte("i am a secret"))
+	}
+
+	// A different source key must fail the key commitment
+	e.SourceKey = []byte("otherkey!")
+	if _, err := e.Decrypt(encrypted); err != ErrInvalidHmac {
+		t.Errorf("Decrypt() with wrong key error = %v, want %v", err, ErrInvalidHmac)
+	}
+}
+
 // Ensure errors are passed up to the caller
 func TestKDFEncrypt(t *testing.T) {
 	tests := []struct {
@@ -1,7 +1,10 @@
 package encryptor
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kms"
 )
@@ -16,8 +19,9 @@ type KMS struct {
 }
 
 type kmsInterface interface {
-	GenerateDataKey(input *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error)
-	Decrypt(input *kms.DecryptInput) (*kms.DecryptOutput, error)
+	GenerateDataKeyWithContext(ctx aws.Context, input *kms.GenerateDataKeyInput, opts ...request.Option) (*kms.GenerateDataKeyOutput, error)
+	DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error)
+	EncryptWithContext(ctx aws.Context, input *kms.EncryptInput, opts ...request.Option) (*kms.EncryptOutput, error)
 }
 
 // NewKMS returns an initialised Encryptor using Amazon KMS to wrap the
@@ -49,8 +53,14 @@ func NewKMS(keyID, region string) *KMS {
 // Encrypt generates a new encryption key using Amazon KMS, passing it to the
 // configured EncryptionProvider as the encryption key to encrypt the secret.
 func (e *KMS) Encrypt(secret []byte) (*EncryptedData, error) {
+	return e.EncryptContext(context.Background(), secret)
+}
+
+// EncryptContext is the same as Encrypt, but the request to Amazon KMS is
+// cancelled if ctx is cancelled before it completes.
+func (e *KMS) EncryptContext(ctx context.Context, secret []byte) (*EncryptedData, error) {
 	// Ask KMS for a 64 byte encryption key
-	resp, err := e.svc.GenerateDataKey(&kms.GenerateDataKeyInput{
+	resp, err := e.svc.GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{
 		KeyId:         &e.keyID,
 		NumberOfBytes: aws.Int64(e.KeySize),
 	})
@@ -90,6 +100,12 @@ func (e *KMS) Encrypt(secret []byte) (*EncryptedData, error) {
 // Decrypt decrypts the embedded encyption key using Amazon KMS, and then passes
 // the plain-text key to the EncryptionProvider to decrypt the secret.
 func (e *KMS) Decrypt(data *EncryptedData) ([]byte, error) {
+	return e.DecryptContext(context.Background(), data)
+}
+
+// DecryptContext is the same as Decrypt, but the request to Amazon KMS is
+// cancelled if ctx is cancelled before it completes.
+func (e *KMS) DecryptContext(ctx context.Context, data *EncryptedData) ([]byte, error) {
 	// Ensure this data was wrapped
 	if data.Type != KMSWrapped {
 		return []byte{}, ErrWrongType
@@ -119,7 +135,7 @@ func (e *KMS) Decrypt(data *EncryptedData) ([]byte, error) {
 	}
 
 	// Decrypt the key
-	resp, err := e.svc.Decrypt(&kms.DecryptInput{CiphertextBlob: kmsKey})
+	resp, err := e.svc.DecryptWithContext(ctx, &kms.DecryptInput{CiphertextBlob: kmsKey})
 	if err != nil {
 		return []byte{}, err
 	}
@@ -0,0 +1,93 @@
+package encryptor
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// kmsDirectMaxSize is the largest plain-text accepted by the KMS Encrypt API.
+const kmsDirectMaxSize = 4096
+
+// KMSDirectEncryptor encrypts secrets directly with Amazon KMS, so the
+// plain-text is never handled by a local encryption provider and access is
+// governed only by the KMS key policy.
+//
+// KMS limits the size of data it will encrypt, so secrets larger than MaxSize
+// (or empty secrets) are encrypted using envelope encryption with the embedded
+// KMS Encryptor instead.
+type KMSDirectEncryptor struct {
+	*KMS
+	MaxSize int
+}
+
+// NewKMSDirect returns an initialised Encryptor using Amazon KMS to directly
+// encrypt secrets up to 4KB in size, falling back to KMS envelope encryption
+// for larger secrets.
+func NewKMSDirect(keyID, region string) *KMSDirectEncryptor {
+	return &KMSDirectEncryptor{
+		KMS:     NewKMS(keyID, region),
+		MaxSize: kmsDirectMaxSize,
+	}
+}
+
+// Encrypt sends the secret to Amazon KMS for encryption, or uses envelope
+// encryption if the secret is larger than MaxSize.
+func (e *KMSDirectEncryptor) Encrypt(secret []byte) (*EncryptedData, error) {
+	return e.EncryptContext(context.Background(), secret)
+}
+
+// EncryptContext is the same as Encrypt, but the request to Amazon KMS is
+// cancelled if ctx is cancelled before it completes.
+func (e *KMSDirectEncryptor) EncryptContext(ctx context.Context, secret []byte) (*EncryptedData, error) {
+	// KMS refuses to encrypt nothing
+	if len(secret) == 0 || len(secret) > e.MaxSize {
+		return e.KMS.EncryptContext(ctx, secret)
+	}
+
+	resp, err := e.svc.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     &e.keyID,
+		Plaintext: secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// KMS authenticates the ciphertext blob, so there's no need for a HMAC
+	return &EncryptedData{
+		Ciphertext: resp.CiphertextBlob,
+		Type:       KMSDirect,
+	}, nil
+}
+
+// Decrypt sends data to Amazon KMS for decryption, or passes it to the
+// embedded KMS Encryptor if it was encrypted using envelope encryption.
+func (e *KMSDirectEncryptor) Decrypt(data *EncryptedData) ([]byte, error) {
+	return e.DecryptContext(context.Background(), data)
+}
+
+// DecryptContext is the same as Decrypt, but the request to Amazon KMS is
+// cancelled if ctx is cancelled before it completes.
+func (e *KMSDirectEncryptor) DecryptContext(ctx context.Context, data *EncryptedData) ([]byte, error) {
+	switch data.Type {
+	case KMSDirect:
+		break
+
+	case KMSWrapped:
+		return e.KMS.DecryptContext(ctx, data)
+
+	default:
+		return []byte{}, ErrWrongType
+	}
+
+	if len(data.Ciphertext) == 0 {
+		return []byte{}, ErrInvalidCiphertext
+	}
+
+	resp, err := e.svc.DecryptWithContext(ctx, &kms.DecryptInput{CiphertextBlob: data.Ciphertext})
+	if err != nil {
+		return []byte{}, err
+	}
+
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,244 @@
+package encryptor
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestKMSDirectEncrypt(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Receiver fields.
+		rsvc     kmsInterface
+		rkeyID   string
+		rMaxSize int
+		// Parameters.
+		secret []byte
+		// Expected results.
+		wantType uint8
+		wantErr  error
+	}{
+		{
+			"Direct",
+			&mockKms{keyID: "keyId"},
+			"keyId",
+			kmsDirectMaxSize,
+			[]byte("secret"),
+			KMSDirect,
+			nil,
+		},
+		{
+			"Max size",
+			&mockKms{keyID: "keyId"},
+			"keyId",
+			6,
+			[]byte("secret"),
+			KMSDirect,
+			nil,
+		},
+		{
+			"Too large, envelope",
+			&mockKms{keyID: "keyId"},
+			"keyId",
+			5,
+			[]byte("secret"),
+			KMSWrapped,
+			nil,
+		},
+		{
+			"Empty, envelope",
+			&mockKms{keyID: "keyId"},
+			"keyId",
+			kmsDirectMaxSize,
+			[]byte{},
+			KMSWrapped,
+			nil,
+		},
+		{
+			"KMS request error",
+			&mockKms{keyID: "keyId", err: errMarker},
+			"keyId",
+			kmsDirectMaxSize,
+			[]byte("secret"),
+			KMSDirect,
+			errMarker,
+		},
+	}
+
+	for _, tt := range tests {
+		e := &KMSDirectEncryptor{
+			KMS: &KMS{
+				svc:     tt.rsvc,
+				keyID:   tt.rkeyID,
+				KeySize: 64,
+				Provider: func(key []byte) (EncryptDecryptor, error) {
+					return NopEncryptor{}, nil
+				},
+			},
+			MaxSize: tt.rMaxSize,
+		}
+
+		got, err := e.Encrypt(tt.secret)
+		if err != tt.wantErr {
+			t.Errorf("%q. KMSDirectEncryptor.Encrypt() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if got.Type != tt.wantType {
+			t.Errorf("%q. KMSDirectEncryptor.Encrypt() type = %v, want %v", tt.name, got.Type, tt.wantType)
+		}
+	}
+}
+
+func TestKMSDirectDecrypt(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Receiver fields.
+		rsvc kmsInterface
+		// Parameters.
+		data *EncryptedData
+		// Expected results.
+		want    []byte
+		wantErr error
+	}{
+		{
+			"Direct",
+			&mockKms{keyID: "keyId"},
+			&EncryptedData{
+				Ciphertext: []byte("direct:secret"),
+				Type:       KMSDirect,
+			},
+			[]byte("secret"),
+			nil,
+		},
+		{
+			"Envelope",
+			&mockKms{keyID: "keyId"},
+			&EncryptedData{
+				Ciphertext: []byte("secret"),
+				HMAC:       []byte("--ignored--"),
+				Type:       KMSWrapped,
+				Context: map[string]interface{}{
+					"kms_type": Nop,
+					"kms_key":  []byte("AAAA"),
+				},
+			},
+			[]byte("secret"),
+			nil,
+		},
+		{
+			"Wrong type",
+			&mockKms{keyID: "keyId"},
+			&EncryptedData{
+				Ciphertext: []byte("direct:secret"),
+				Type:       Nop,
+			},
+			nil,
+			ErrWrongType,
+		},
+		{
+			"No ciphertext",
+			&mockKms{keyID: "keyId"},
+			&EncryptedData{
+				Type: KMSDirect,
+			},
+			nil,
+			ErrInvalidCiphertext,
+		},
+		{
+			"KMS request error",
+			&mockKms{keyID: "keyId", err: errMarker},
+			&EncryptedData{
+				Ciphertext: []byte("direct:secret"),
+				Type:       KMSDirect,
+			},
+			nil,
+			errMarker,
+		},
+	}
+
+	for _, tt := range tests {
+		e := &KMSDirectEncryptor{
+			KMS: &KMS{
+				svc:     tt.rsvc,
+				keyID:   "keyId",
+				KeySize: 64,
+				Provider: func(key []byte) (EncryptDecryptor, error) {
+					return NopEncryptor{}, nil
+				},
+			},
+			MaxSize: kmsDirectMaxSize,
+		}
+
+		got, err := e.Decrypt(tt.data)
+		if err != tt.wantErr {
+			t.Errorf("%q. KMSDirectEncryptor.Decrypt() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q. KMSDirectEncryptor.Decrypt() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestKMSDirectIntegration ensures secrets either side of MaxSize are returned
+// unchanged.
+func TestKMSDirectIntegration(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		secret []byte
+	}{
+		{
+			"Direct",
+			[]byte("secret"),
+		},
+		{
+			"Envelope",
+			bytes.Repeat([]byte("A"), kmsDirectMaxSize+1),
+		},
+	}
+
+	for _, tt := range tests {
+		e := &KMSDirectEncryptor{
+			KMS: &KMS{
+				svc:     &mockKms{keyID: "keyId"},
+				keyID:   "keyId",
+				KeySize: 64,
+				Provider: func(key []byte) (EncryptDecryptor, error) {
+					return NewAES(key[:32], key[32:])
+				},
+			},
+			MaxSize: kmsDirectMaxSize,
+		}
+
+		encd, err := e.Encrypt(tt.secret)
+		if err != nil {
+			t.Errorf("%q. KMSDirectEncryptor.Encrypt() error = %v", tt.name, err)
+			continue
+		}
+
+		got, err := e.Decrypt(encd)
+		if err != nil {
+			t.Errorf("%q. KMSDirectEncryptor.Decrypt() error = %v", tt.name, err)
+			continue
+		}
+
+		if !bytes.Equal(got, tt.secret) {
+			t.Errorf("%q. KMSDirectEncryptor.Decrypt() = %v, want %v", tt.name, got, tt.secret)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/kms"
 )
 
@@ -20,7 +21,11 @@ type mockKms struct {
 	err   error
 }
 
-func (m *mockKms) GenerateDataKey(input *kms.GenerateDataKeyInput) (*kms.GenerateDataKeyOutput, error) {
+func (m *mockKms) GenerateDataKeyWithContext(ctx aws.Context, input *kms.GenerateDataKeyInput, opts ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -36,17 +41,48 @@ func (m *mockKms) GenerateDataKey(input *kms.GenerateDataKeyInput) (*kms.Generat
 	}, nil
 }
 
-func (m *mockKms) Decrypt(input *kms.DecryptInput) (*kms.DecryptOutput, error) {
+func (m *mockKms) DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if m.err != nil {
 		return nil, m.err
 	}
 
+	// Return the original plain-text for data encrypted by Encrypt()
+	if bytes.HasPrefix(input.CiphertextBlob, []byte("direct:")) {
+		return &kms.DecryptOutput{
+			KeyId:     aws.String("KEY"),
+			Plaintext: input.CiphertextBlob[len("direct:"):],
+		}, nil
+	}
+
 	return &kms.DecryptOutput{
 		KeyId:     aws.String("KEY"),
 		Plaintext: []byte("XXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXYYYYYYYYYYYYYYYYYYYYYYYYYYYYYYYY"),
 	}, nil
 }
 
+func (m *mockKms) EncryptWithContext(ctx aws.Context, input *kms.EncryptInput, opts ...request.Option) (*kms.EncryptOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if m.keyID != *input.KeyId {
+		return nil, errGenerateDataKey
+	}
+
+	return &kms.EncryptOutput{
+		CiphertextBlob: append([]byte("direct:"), input.Plaintext...),
+		KeyId:          aws.String("KEY"),
+	}, nil
+}
+
 type errEncryptor struct {
 	err error
 }
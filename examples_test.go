@@ -36,3 +36,25 @@ func Example() {
 	fmt.Printf("%s\n", plain)
 	// Output: something secret
 }
+
+// GetMany fetches and decrypts many secrets at once, such as when preloading
+// secrets at startup.
+func ExampleGetMany() {
+	store := store.NewMemory()
+	e, _ := encryptor.NewAESGCM([]byte("anAesTestKey1234"))
+
+	for _, name := range []string{"db_password", "api_key"} {
+		result, _ := e.Encrypt([]byte("secret " + name))
+		store.Put(name, result)
+	}
+
+	plain, errs := GetMany(store, e, []string{"db_password", "api_key", "missing"}, nil)
+
+	fmt.Printf("%s\n", plain["db_password"])
+	fmt.Printf("%s\n", plain["api_key"])
+	fmt.Println(errs["missing"])
+	// Output:
+	// secret db_password
+	// secret api_key
+	// store: secret not found
+}
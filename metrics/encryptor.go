@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// Encryptor wraps an encryptor, recording metrics for every call to the
+// underlying encryptor in Registry, and wrapping each in a span if Tracer is
+// set.
+//
+// Wrapping a KMS encryptor records the latency of the calls to Amazon KMS.
+type Encryptor struct {
+	Encryptor encryptor.EncryptDecryptor
+	Registry  *Registry
+	Tracer    Tracer
+}
+
+// NewEncryptor returns an initialised Encryptor wrapping e, recording metrics
+// in r.
+func NewEncryptor(e encryptor.EncryptDecryptor, r *Registry) *Encryptor {
+	return &Encryptor{
+		Encryptor: e,
+		Registry:  r,
+	}
+}
+
+// Encrypt encrypts secret using the underlying encryptor.
+func (e *Encryptor) Encrypt(secret []byte) (*encryptor.EncryptedData, error) {
+	var data *encryptor.EncryptedData
+
+	err := e.instrument(context.Background(), "encrypt", func(ctx context.Context) error {
+		var err error
+		data, err = e.Encryptor.Encrypt(secret)
+		return err
+	})
+
+	return data, err
+}
+
+// EncryptContext encrypts secret using the underlying encryptor, returning
+// early if ctx is cancelled.
+func (e *Encryptor) EncryptContext(ctx context.Context, secret []byte) (*encryptor.EncryptedData, error) {
+	var data *encryptor.EncryptedData
+
+	err := e.instrument(ctx, "encrypt", func(ctx context.Context) error {
+		var err error
+		data, err = encryptor.WithContext(e.Encryptor).EncryptContext(ctx, secret)
+		return err
+	})
+
+	return data, err
+}
+
+// Decrypt decrypts data using the underlying encryptor.
+func (e *Encryptor) Decrypt(data *encryptor.EncryptedData) ([]byte, error) {
+	var plain []byte
+
+	err := e.instrument(context.Background(), "decrypt", func(ctx context.Context) error {
+		var err error
+		plain, err = e.Encryptor.Decrypt(data)
+		return err
+	})
+
+	return plain, err
+}
+
+// DecryptContext decrypts data using the underlying encryptor, returning early
+// if ctx is cancelled.
+func (e *Encryptor) DecryptContext(ctx context.Context, data *encryptor.EncryptedData) ([]byte, error) {
+	var plain []byte
+
+	err := e.instrument(ctx, "decrypt", func(ctx context.Context) error {
+		var err error
+		plain, err = encryptor.WithContext(e.Encryptor).DecryptContext(ctx, data)
+		return err
+	})
+
+	return plain, err
+}
+
+// instrument calls fn within an "encryptor.<op>" span, recording the latency
+// and result.
+func (e *Encryptor) instrument(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	return instrument(ctx, e.Registry, e.Tracer, "encryptor", op, fn)
+}
@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+var errMarker = errors.New("any error")
+
+// kmsError implements awserr.Error.
+type kmsError struct{}
+
+func (kmsError) Error() string   { return "AccessDeniedException" }
+func (kmsError) Code() string    { return "AccessDeniedException" }
+func (kmsError) Message() string { return "denied" }
+func (kmsError) OrigErr() error  { return nil }
+
+// mockTracer records the name and error of each finished span.
+type mockTracer struct {
+	spans []string
+}
+
+type mockSpan struct {
+	t    *mockTracer
+	name string
+}
+
+func (m *mockTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, &mockSpan{m, name}
+}
+
+func (s *mockSpan) Finish(err error) {
+	component := strings.SplitN(s.name, ".", 2)[0]
+	s.t.spans = append(s.t.spans, s.name+" "+Classify(component, err))
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		err error
+		// Expected results.
+		want string
+	}{
+		{"No error", nil, ResultOK},
+		{"Not found", store.ErrNotFound, ResultNotFound},
+		{"Already exists", store.ErrAlreadyExists, ResultAlreadyExists},
+		{"Conflict", store.ErrConflict, ResultConflict},
+		{"Invalid HMAC", encryptor.ErrInvalidHmac, ResultInvalidHmac},
+		{"KMS", kmsError{}, ResultKMS},
+		{"Timeout", context.DeadlineExceeded, ResultTimeout},
+		{"Canceled", context.Canceled, ResultCanceled},
+		{"Other", errMarker, ResultOther},
+	}
+
+	for _, tt := range tests {
+		if got := Classify("encryptor", tt.err); got != tt.want {
+			t.Errorf("%q. Classify() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// AWS errors from a store are from S3 or DynamoDB
+	if got := Classify("store", kmsError{}); got != ResultAWS {
+		t.Errorf("Classify(store) = %v, want %v", got, ResultAWS)
+	}
+
+	if got := Classify("store", store.ErrNotFound); got != ResultNotFound {
+		t.Errorf("Classify(store) = %v, want %v", got, ResultNotFound)
+	}
+}
+
+// TestStoreEncryptor ensures each operation is counted by result, and traced.
+func TestStoreEncryptor(t *testing.T) {
+	r := NewRegistry()
+	tracer := &mockTracer{}
+
+	s := NewStore(store.NewMemory(), r)
+	s.Tracer = tracer
+
+	e := NewEncryptor(encryptor.NopEncryptor{}, r)
+	e.Tracer = tracer
+
+	data, _ := e.Encrypt([]byte("secret"))
+	s.Put("secret", data)
+	s.Get("secret")
+	s.Get("missing")
+	s.GetContext(context.Background(), "secret")
+	e.Decrypt(data)
+
+	want := []OpSnapshot{
+		{Component: "encryptor", Op: "decrypt", Results: map[string]uint64{ResultOK: 1}, Count: 1},
+		{Component: "encryptor", Op: "encrypt", Results: map[string]uint64{ResultOK: 1}, Count: 1},
+		{Component: "store", Op: "get", Results: map[string]uint64{ResultOK: 2, ResultNotFound: 1}, Count: 3},
+		{Component: "store", Op: "put", Results: map[string]uint64{ResultOK: 1}, Count: 1},
+	}
+
+	got := r.Snapshot()
+	for i := range got {
+		// Timings vary between runs
+		got[i].Buckets = nil
+		got[i].Sum = 0
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.Snapshot() = %+v, want %+v", got, want)
+	}
+
+	wantSpans := []string{
+		"encryptor.encrypt ok",
+		"store.put ok",
+		"store.get ok",
+		"store.get not_found",
+		"store.get ok",
+		"encryptor.decrypt ok",
+	}
+
+	if !reflect.DeepEqual(tracer.spans, wantSpans) {
+		t.Errorf("spans = %v, want %v", tracer.spans, wantSpans)
+	}
+}
+
+// TestStoreGetManyUnsupported ensures GetMany only fetches in bulk when the
+// underlying store can, without recording an operation.
+func TestStoreGetManyUnsupported(t *testing.T) {
+	r := NewRegistry()
+	s := NewStore(struct{ store.Interface }{store.NewMemory()}, r)
+
+	if _, err := s.GetMany([]string{"secret"}); err != store.ErrBulkUnsupported {
+		t.Errorf("Store.GetMany() error = %v, want %v", err, store.ErrBulkUnsupported)
+	}
+
+	if got := r.Snapshot(); len(got) != 0 {
+		t.Errorf("Registry.Snapshot() = %+v, want none", got)
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	r := NewRegistry()
+	s := NewStore(store.NewMemory(), r)
+	s.Get("missing")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{
+		`cryptic_operations_total{component="store",op="get",result="not_found"} 1`,
+		`cryptic_operation_duration_seconds_bucket{component="store",op="get",le="10"} 1`,
+		`cryptic_operation_duration_seconds_bucket{component="store",op="get",le="+Inf"} 1`,
+		`cryptic_operation_duration_seconds_count{component="store",op="get"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Registry.ServeHTTP() missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	r := NewRegistry()
+	r.Publish("cryptic_test")
+
+	NewStore(store.NewMemory(), r).Get("missing")
+
+	got := []OpSnapshot{}
+	if err := json.NewDecoder(bytes.NewBufferString(expvar.Get("cryptic_test").String())).Decode(&got); err != nil {
+		t.Fatalf("failed to decode expvar: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Results[ResultNotFound] != 1 {
+		t.Errorf("expvar = %+v, want one not_found get", got)
+	}
+}
@@ -0,0 +1,7 @@
+// Package metrics instruments stores and encryptors, recording the number of
+// operations, their latency and the class of any errors.
+//
+// Metrics are collected in a Registry, which can be published with expvar or
+// served over HTTP in the Prometheus text format. A Tracer can optionally be
+// set to wrap each backend and KMS call in a span.
+package metrics
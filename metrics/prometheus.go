@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ServeHTTP writes the recorded metrics in the Prometheus text exposition
+// format, allowing the Registry to be scraped directly:
+//
+//	http.Handle("/metrics", registry)
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WritePrometheus(w)
+}
+
+// WritePrometheus writes the recorded metrics to w in the Prometheus text
+// exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	snaps := r.Snapshot()
+	buf := bufio.NewWriter(w)
+
+	fmt.Fprintln(buf, "# HELP cryptic_operations_total Number of operations by result.")
+	fmt.Fprintln(buf, "# TYPE cryptic_operations_total counter")
+	for _, s := range snaps {
+		results := make([]string, 0, len(s.Results))
+		for res := range s.Results {
+			results = append(results, res)
+		}
+		sort.Strings(results)
+
+		for _, res := range results {
+			fmt.Fprintf(buf, "cryptic_operations_total{component=%q,op=%q,result=%q} %d\n",
+				s.Component, s.Op, res, s.Results[res])
+		}
+	}
+
+	fmt.Fprintln(buf, "# HELP cryptic_operation_duration_seconds Operation latency.")
+	fmt.Fprintln(buf, "# TYPE cryptic_operation_duration_seconds histogram")
+	for _, s := range snaps {
+		for _, b := range buckets {
+			fmt.Fprintf(buf, "cryptic_operation_duration_seconds_bucket{component=%q,op=%q,le=%q} %d\n",
+				s.Component, s.Op, strconv.FormatFloat(b, 'g', -1, 64), s.Buckets[b])
+		}
+
+		fmt.Fprintf(buf, "cryptic_operation_duration_seconds_bucket{component=%q,op=%q,le=\"+Inf\"} %d\n",
+			s.Component, s.Op, s.Count)
+		fmt.Fprintf(buf, "cryptic_operation_duration_seconds_sum{component=%q,op=%q} %s\n",
+			s.Component, s.Op, strconv.FormatFloat(s.Sum, 'g', -1, 64))
+		fmt.Fprintf(buf, "cryptic_operation_duration_seconds_count{component=%q,op=%q} %d\n",
+			s.Component, s.Op, s.Count)
+	}
+
+	return buf.Flush()
+}
@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+// Error classes recorded for each operation.
+const (
+	ResultOK            = "ok"
+	ResultNotFound      = "not_found"
+	ResultAlreadyExists = "already_exists"
+	ResultConflict      = "conflict"
+	ResultInvalidHmac   = "invalid_hmac"
+	ResultKMS           = "kms"
+	ResultAWS           = "aws"
+	ResultTimeout       = "timeout"
+	ResultCanceled      = "canceled"
+	ResultOther         = "other"
+)
+
+// buckets are the upper bounds (in seconds) of the latency histogram.
+var buckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry collects the metrics recorded by the instrumented stores and
+// encryptors.
+//
+// A single Registry can be shared by any number of wrappers.
+type Registry struct {
+	mu  sync.Mutex
+	ops map[opKey]*opStats
+}
+
+type opKey struct {
+	component string
+	op        string
+}
+
+type opStats struct {
+	results map[string]uint64
+	// counts holds the number of observations in each bucket, with the final
+	// element counting those larger than the last bucket.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewRegistry returns an initialised, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{ops: map[opKey]*opStats{}}
+}
+
+// observe records a single call to op taking d, with the outcome err.
+func (r *Registry) observe(component, op string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := opKey{component, op}
+	s, ok := r.ops[k]
+	if !ok {
+		s = &opStats{
+			results: map[string]uint64{},
+			counts:  make([]uint64, len(buckets)+1),
+		}
+		r.ops[k] = s
+	}
+
+	s.results[Classify(component, err)]++
+
+	secs := d.Seconds()
+	i := sort.SearchFloat64s(buckets, secs)
+	s.counts[i]++
+	s.sum += secs
+	s.count++
+}
+
+// Classify returns the error class recorded for err, returned by component
+// ("store" or "encryptor", the component of an OpSnapshot).
+//
+// AWS errors are classified by the service the component calls - Amazon KMS is
+// the only AWS service called by an encryptor, so they are recorded as KMS
+// errors, while those from a store (S3 or DynamoDB) are recorded as AWS errors.
+func Classify(component string, err error) string {
+	switch err {
+	case nil:
+		return ResultOK
+
+	case store.ErrNotFound:
+		return ResultNotFound
+
+	case store.ErrAlreadyExists:
+		return ResultAlreadyExists
+
+	case store.ErrConflict:
+		return ResultConflict
+
+	case encryptor.ErrInvalidHmac:
+		return ResultInvalidHmac
+
+	case context.DeadlineExceeded:
+		return ResultTimeout
+
+	case context.Canceled:
+		return ResultCanceled
+	}
+
+	if _, ok := err.(awserr.Error); ok {
+		if component == "store" {
+			return ResultAWS
+		}
+
+		return ResultKMS
+	}
+
+	return ResultOther
+}
+
+// OpSnapshot holds the metrics recorded for a single operation.
+type OpSnapshot struct {
+	Component string            `json:"component"`
+	Op        string            `json:"op"`
+	Results   map[string]uint64 `json:"results"`
+	// Buckets maps each histogram upper bound to the cumulative number of
+	// observations no larger than it.
+	Buckets map[float64]uint64 `json:"-"`
+	Sum     float64            `json:"latency_sum_seconds"`
+	Count   uint64             `json:"count"`
+}
+
+// Snapshot returns a copy of the metrics recorded for each operation, ordered
+// by component and operation name.
+func (r *Registry) Snapshot() []OpSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]OpSnapshot, 0, len(r.ops))
+	for k, s := range r.ops {
+		snap := OpSnapshot{
+			Component: k.component,
+			Op:        k.op,
+			Results:   map[string]uint64{},
+			Buckets:   map[float64]uint64{},
+			Sum:       s.sum,
+			Count:     s.count,
+		}
+
+		for res, n := range s.results {
+			snap.Results[res] = n
+		}
+
+		var total uint64
+		for i, b := range buckets {
+			total += s.counts[i]
+			snap.Buckets[b] = total
+		}
+
+		out = append(out, snap)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Component != out[j].Component {
+			return out[i].Component < out[j].Component
+		}
+		return out[i].Op < out[j].Op
+	})
+
+	return out
+}
+
+// Publish exposes the registry with expvar under name.
+//
+// Like expvar.Publish, Publish panics if name is already in use.
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return r.Snapshot()
+	}))
+}
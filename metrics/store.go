@@ -0,0 +1,340 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/domodwyer/cryptic/store"
+)
+
+// Store wraps a store, recording metrics for every call to the underlying
+// store in Registry, and wrapping each in a span if Tracer is set.
+type Store struct {
+	Store    store.Interface
+	Registry *Registry
+	Tracer   Tracer
+}
+
+// NewStore returns an initialised Store wrapping s, recording metrics in r.
+func NewStore(s store.Interface, r *Registry) *Store {
+	return &Store{
+		Store:    s,
+		Registry: r,
+	}
+}
+
+// Put stores data in the underlying store.
+func (s *Store) Put(name string, data *encryptor.EncryptedData) error {
+	return s.instrument(context.Background(), "put", func(ctx context.Context) error {
+		return s.Store.Put(name, data)
+	})
+}
+
+// PutWithTTL stores data in the underlying store, expiring it after ttl.
+func (s *Store) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	e, ok := s.Store.(store.Expirer)
+	if !ok {
+		return store.ErrExpiryUnsupported
+	}
+
+	return s.instrument(context.Background(), "put_with_ttl", func(ctx context.Context) error {
+		return e.PutWithTTL(name, data, ttl)
+	})
+}
+
+// PutMetadata stores m as the metadata of name in the underlying store.
+func (s *Store) PutMetadata(name string, m *store.Metadata) error {
+	d, ok := s.Store.(store.Describer)
+	if !ok {
+		return store.ErrMetadataUnsupported
+	}
+
+	return s.instrument(context.Background(), "put_metadata", func(ctx context.Context) error {
+		return d.PutMetadata(name, m)
+	})
+}
+
+// GetMetadata fetches the metadata of name from the underlying store.
+func (s *Store) GetMetadata(name string) (*store.Metadata, error) {
+	d, ok := s.Store.(store.Describer)
+	if !ok {
+		return nil, store.ErrMetadataUnsupported
+	}
+
+	var m *store.Metadata
+
+	err := s.instrument(context.Background(), "get_metadata", func(ctx context.Context) error {
+		var err error
+		m, err = d.GetMetadata(name)
+		return err
+	})
+
+	return m, err
+}
+
+// PutContext stores data in the underlying store, returning early if ctx is
+// cancelled.
+func (s *Store) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	return s.instrument(ctx, "put", func(ctx context.Context) error {
+		return store.WithContext(s.Store).PutContext(ctx, name, data)
+	})
+}
+
+// Get fetches the secret from the underlying store.
+func (s *Store) Get(name string) (*encryptor.EncryptedData, error) {
+	var data *encryptor.EncryptedData
+
+	err := s.instrument(context.Background(), "get", func(ctx context.Context) error {
+		var err error
+		data, err = s.Store.Get(name)
+		return err
+	})
+
+	return data, err
+}
+
+// GetContext fetches the secret from the underlying store, returning early if
+// ctx is cancelled.
+func (s *Store) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	var data *encryptor.EncryptedData
+
+	err := s.instrument(ctx, "get", func(ctx context.Context) error {
+		var err error
+		data, err = store.WithContext(s.Store).GetContext(ctx, name)
+		return err
+	})
+
+	return data, err
+}
+
+// GetMany fetches the named secrets from the underlying store in a single
+// operation.
+//
+// If the underlying store doesn't implement store.BulkGetter,
+// store.ErrBulkUnsupported is returned.
+func (s *Store) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	bulk, ok := s.Store.(store.BulkGetter)
+	if !ok {
+		return nil, store.ErrBulkUnsupported
+	}
+
+	var out map[string]*encryptor.EncryptedData
+
+	err := s.instrument(context.Background(), "get_many", func(ctx context.Context) error {
+		var err error
+		out, err = bulk.GetMany(names)
+		return err
+	})
+
+	return out, err
+}
+
+// List lists the names in the underlying store.
+//
+// If the underlying store doesn't implement store.Lister,
+// store.ErrListUnsupported is returned.
+func (s *Store) List(opts *store.ListOpts) ([]string, string, error) {
+	l, ok := s.Store.(store.Lister)
+	if !ok {
+		return nil, "", store.ErrListUnsupported
+	}
+
+	var names []string
+	var cursor string
+
+	err := s.instrument(context.Background(), "list", func(ctx context.Context) error {
+		var err error
+		names, cursor, err = l.List(opts)
+		return err
+	})
+
+	return names, cursor, err
+}
+
+// Watch watches the underlying store, recording the time taken to start the
+// watch.
+//
+// If the underlying store doesn't implement store.Watcher,
+// store.ErrWatchUnsupported is returned.
+func (s *Store) Watch(ctx context.Context, prefix string) (<-chan store.WatchEvent, error) {
+	w, ok := s.Store.(store.Watcher)
+	if !ok {
+		return nil, store.ErrWatchUnsupported
+	}
+
+	var events <-chan store.WatchEvent
+
+	// The watch outlives the span, so it's started with the caller's ctx
+	err := s.instrument(ctx, "watch", func(context.Context) error {
+		var err error
+		events, err = w.Watch(ctx, prefix)
+		return err
+	})
+
+	return events, err
+}
+
+// Delete removes the secret from the underlying store.
+func (s *Store) Delete(name string) error {
+	return s.instrument(context.Background(), "delete", func(ctx context.Context) error {
+		return s.Store.Delete(name)
+	})
+}
+
+// DeleteContext removes the secret from the underlying store, returning early
+// if ctx is cancelled.
+func (s *Store) DeleteContext(ctx context.Context, name string) error {
+	return s.instrument(ctx, "delete", func(ctx context.Context) error {
+		return store.WithContext(s.Store).DeleteContext(ctx, name)
+	})
+}
+
+// PutVersion stores data as the next version of name in the underlying store.
+func (s *Store) PutVersion(name string, data *encryptor.EncryptedData) (int, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return 0, store.ErrVersioningUnsupported
+	}
+
+	var version int
+
+	err := s.instrument(context.Background(), "put_version", func(ctx context.Context) error {
+		var err error
+		version, err = v.PutVersion(name, data)
+		return err
+	})
+
+	return version, err
+}
+
+// GetVersion fetches the given version of name from the underlying store.
+func (s *Store) GetVersion(name string, version int) (*encryptor.EncryptedData, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return nil, store.ErrVersioningUnsupported
+	}
+
+	var data *encryptor.EncryptedData
+
+	err := s.instrument(context.Background(), "get_version", func(ctx context.Context) error {
+		var err error
+		data, err = v.GetVersion(name, version)
+		return err
+	})
+
+	return data, err
+}
+
+// Versions returns the versions of name held in the underlying store.
+func (s *Store) Versions(name string) ([]int, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return nil, store.ErrVersioningUnsupported
+	}
+
+	var versions []int
+
+	err := s.instrument(context.Background(), "versions", func(ctx context.Context) error {
+		var err error
+		versions, err = v.Versions(name)
+		return err
+	})
+
+	return versions, err
+}
+
+// Prune removes all but the keep most recent versions of name from the
+// underlying store.
+func (s *Store) Prune(name string, keep int) (int, error) {
+	v, ok := s.Store.(store.Versioner)
+	if !ok {
+		return 0, store.ErrVersioningUnsupported
+	}
+
+	var removed int
+
+	err := s.instrument(context.Background(), "prune", func(ctx context.Context) error {
+		var err error
+		removed, err = v.Prune(name, keep)
+		return err
+	})
+
+	return removed, err
+}
+
+// CompareAndSwapVersion stores data as the next version of name in the
+// underlying store, if the latest version is version.
+func (s *Store) CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error) {
+	v, ok := s.Store.(store.VersionSwapper)
+	if !ok {
+		return 0, store.ErrVersioningUnsupported
+	}
+
+	var newVersion int
+
+	err := s.instrument(context.Background(), "compare_and_swap_version", func(ctx context.Context) error {
+		var err error
+		newVersion, err = v.CompareAndSwapVersion(name, version, data)
+		return err
+	})
+
+	return newVersion, err
+}
+
+// Update replaces the secret in the underlying store.
+func (s *Store) Update(name string, data *encryptor.EncryptedData) error {
+	u, ok := s.Store.(store.Updater)
+	if !ok {
+		return store.ErrUpdateUnsupported
+	}
+
+	return s.instrument(context.Background(), "update", func(ctx context.Context) error {
+		return u.Update(name, data)
+	})
+}
+
+// Upsert stores data in the underlying store, replacing any existing secret.
+func (s *Store) Upsert(name string, data *encryptor.EncryptedData) error {
+	u, ok := s.Store.(store.Updater)
+	if !ok {
+		return store.ErrUpdateUnsupported
+	}
+
+	return s.instrument(context.Background(), "upsert", func(ctx context.Context) error {
+		return u.Upsert(name, data)
+	})
+}
+
+// CompareAndSwap replaces the secret in the underlying store if its
+// cipher-text matches old.
+func (s *Store) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	u, ok := s.Store.(store.Updater)
+	if !ok {
+		return store.ErrUpdateUnsupported
+	}
+
+	return s.instrument(context.Background(), "compare_and_swap", func(ctx context.Context) error {
+		return u.CompareAndSwap(name, old, data)
+	})
+}
+
+// instrument calls fn within a "store.<op>" span, recording the latency and
+// result.
+func (s *Store) instrument(ctx context.Context, op string, fn func(ctx context.Context) error) error {
+	return instrument(ctx, s.Registry, s.Tracer, "store", op, fn)
+}
+
+// instrument calls fn within a "<component>.<op>" span, recording the latency
+// and result in r.
+func instrument(ctx context.Context, r *Registry, t Tracer, component, op string, fn func(ctx context.Context) error) error {
+	ctx, span := startSpan(ctx, t, component+"."+op)
+
+	start := time.Now()
+	err := fn(ctx)
+
+	r.observe(component, op, time.Since(start), err)
+	span.Finish(err)
+
+	return err
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import "context"
+
+// Tracer is implemented by tracing systems to wrap each store and encryptor
+// call in a span - adapt it to OpenTracing, OpenCensus, etc.
+//
+// StartSpan should return a context carrying the new span, which is passed to
+// the underlying store or encryptor if it supports contexts.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	// Finish ends the span, recording err if the operation failed.
+	Finish(err error)
+}
+
+type nopSpan struct{}
+
+func (nopSpan) Finish(err error) {}
+
+// startSpan starts a span using t, or returns a no-op span if t is nil.
+func startSpan(ctx context.Context, t Tracer, name string) (context.Context, Span) {
+	if t == nil {
+		return ctx, nopSpan{}
+	}
+
+	return t.StartSpan(ctx, name)
+}
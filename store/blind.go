@@ -0,0 +1,527 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// Blinder maps a secret name to an opaque name used as the key in the
+// underlying store.
+//
+// Implementations must be deterministic - the same name must always produce
+// the same blinded name.
+type Blinder interface {
+	Blind(name string) (string, error)
+}
+
+// Unblinder is implemented by a Blinder that can reverse the blinding
+// operation, recovering the original secret name.
+type Unblinder interface {
+	Unblind(blinded string) (string, error)
+}
+
+// Blinded wraps a store, passing every secret name through Blinder before it
+// reaches the underlying store so the names of stored secrets are not revealed
+// to anyone able to read the backend.
+//
+// Blinded works with any store, and the underlying store sees only the blinded
+// names.
+type Blinded struct {
+	Store   Interface
+	Blinder Blinder
+}
+
+// NewBlinded returns an initalised Blinded store, wrapping s.
+func NewBlinded(s Interface, b Blinder) *Blinded {
+	return &Blinded{
+		Store:   s,
+		Blinder: b,
+	}
+}
+
+// Put blinds name and stores data in the underlying store.
+func (s *Blinded) Put(name string, data *encryptor.EncryptedData) error {
+	b, err := s.blind(name)
+	if err != nil {
+		return err
+	}
+
+	return s.Store.Put(b, data)
+}
+
+// PutWithTTL blinds name and stores data in the underlying store, expiring it
+// after ttl.
+func (s *Blinded) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	e, ok := s.Store.(Expirer)
+	if !ok {
+		return ErrExpiryUnsupported
+	}
+
+	b, err := s.blind(name)
+	if err != nil {
+		return err
+	}
+
+	return e.PutWithTTL(b, data, ttl)
+}
+
+// PutMetadata blinds name and stores m in the underlying store.
+//
+// The metadata itself is not blinded - avoid putting anything in it that
+// reveals the name of the secret.
+func (s *Blinded) PutMetadata(name string, m *Metadata) error {
+	d, ok := s.Store.(Describer)
+	if !ok {
+		return ErrMetadataUnsupported
+	}
+
+	b, err := s.blind(name)
+	if err != nil {
+		return err
+	}
+
+	return d.PutMetadata(b, m)
+}
+
+// GetMetadata blinds name and fetches its metadata from the underlying store.
+func (s *Blinded) GetMetadata(name string) (*Metadata, error) {
+	d, ok := s.Store.(Describer)
+	if !ok {
+		return nil, ErrMetadataUnsupported
+	}
+
+	b, err := s.blind(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.GetMetadata(b)
+}
+
+// PutContext blinds name and stores data in the underlying store, returning
+// early if ctx is cancelled.
+func (s *Blinded) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	b, err := s.blind(name)
+	if err != nil {
+		return err
+	}
+
+	return WithContext(s.Store).PutContext(ctx, b, data)
+}
+
+// Get blinds name and fetches the secret from the underlying store.
+func (s *Blinded) Get(name string) (*encryptor.EncryptedData, error) {
+	b, err := s.blind(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Store.Get(b)
+}
+
+// GetContext blinds name and fetches the secret from the underlying store,
+// returning early if ctx is cancelled.
+func (s *Blinded) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	b, err := s.blind(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithContext(s.Store).GetContext(ctx, b)
+}
+
+// GetMany blinds each of names and fetches the secrets from the underlying
+// store in a single operation. If the underlying store doesn't implement
+// BulkGetter, ErrBulkUnsupported is returned.
+func (s *Blinded) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	bulk, ok := s.Store.(BulkGetter)
+	if !ok {
+		return nil, ErrBulkUnsupported
+	}
+
+	blinded := make([]string, len(names))
+	for i, name := range names {
+		b, err := s.blind(name)
+		if err != nil {
+			return nil, err
+		}
+
+		blinded[i] = b
+	}
+
+	res, err := bulk.GetMany(blinded)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]*encryptor.EncryptedData{}
+	for i, b := range blinded {
+		if d, ok := res[b]; ok {
+			out[names[i]] = d
+		}
+	}
+
+	return out, nil
+}
+
+// Delete blinds name and removes the secret from the underlying store.
+func (s *Blinded) Delete(name string) error {
+	b, err := s.blind(name)
+	if err != nil {
+		return err
+	}
+
+	return s.Store.Delete(b)
+}
+
+// DeleteContext blinds name and removes the secret from the underlying store,
+// returning early if ctx is cancelled.
+func (s *Blinded) DeleteContext(ctx context.Context, name string) error {
+	b, err := s.blind(name)
+	if err != nil {
+		return err
+	}
+
+	return WithContext(s.Store).DeleteContext(ctx, b)
+}
+
+// PutVersion blinds name and stores data as its next version in the
+// underlying store.
+func (s *Blinded) PutVersion(name string, data *encryptor.EncryptedData) (int, error) {
+	v, b, err := s.versioner(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.PutVersion(b, data)
+}
+
+// GetVersion blinds name and fetches the given version from the underlying
+// store.
+func (s *Blinded) GetVersion(name string, version int) (*encryptor.EncryptedData, error) {
+	v, b, err := s.versioner(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.GetVersion(b, version)
+}
+
+// Versions blinds name and returns its versions held in the underlying store.
+func (s *Blinded) Versions(name string) ([]int, error) {
+	v, b, err := s.versioner(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Versions(b)
+}
+
+// Prune blinds name and removes all but the keep most recent versions from the
+// underlying store.
+func (s *Blinded) Prune(name string, keep int) (int, error) {
+	v, b, err := s.versioner(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Prune(b, keep)
+}
+
+// CompareAndSwapVersion blinds name and stores data as its next version in the
+// underlying store, if the latest version is version.
+func (s *Blinded) CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error) {
+	v, ok := s.Store.(VersionSwapper)
+	if !ok {
+		return 0, ErrVersioningUnsupported
+	}
+
+	b, err := s.blind(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.CompareAndSwapVersion(b, version, data)
+}
+
+// Update blinds name and replaces the secret in the underlying store.
+func (s *Blinded) Update(name string, data *encryptor.EncryptedData) error {
+	u, b, err := s.updater(name)
+	if err != nil {
+		return err
+	}
+
+	return u.Update(b, data)
+}
+
+// Upsert blinds name and stores data in the underlying store, replacing any
+// existing secret.
+func (s *Blinded) Upsert(name string, data *encryptor.EncryptedData) error {
+	u, b, err := s.updater(name)
+	if err != nil {
+		return err
+	}
+
+	return u.Upsert(b, data)
+}
+
+// CompareAndSwap blinds name and replaces the secret in the underlying store if
+// its cipher-text matches old.
+func (s *Blinded) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	u, b, err := s.updater(name)
+	if err != nil {
+		return err
+	}
+
+	return u.CompareAndSwap(b, old, data)
+}
+
+// updater returns the underlying store as an Updater, and the blinded name.
+func (s *Blinded) updater(name string) (Updater, string, error) {
+	u, ok := s.Store.(Updater)
+	if !ok {
+		return nil, "", ErrUpdateUnsupported
+	}
+
+	b, err := s.blind(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return u, b, nil
+}
+
+// versioner returns the underlying store as a Versioner, and the blinded name.
+func (s *Blinded) versioner(name string) (Versioner, string, error) {
+	v, ok := s.Store.(Versioner)
+	if !ok {
+		return nil, "", ErrVersioningUnsupported
+	}
+
+	b, err := s.blind(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return v, b, nil
+}
+
+// blind returns the blinded form of name, rejecting empty names before they
+// reach the Blinder.
+func (s *Blinded) blind(name string) (string, error) {
+	if name == "" {
+		return "", ErrInvalidName
+	}
+
+	return s.Blinder.Blind(name)
+}
+
+// List unblinds the names listed by the underlying store, returning those
+// starting with opts.Prefix.
+//
+// Blinded names don't preserve ordering or prefixes, so every name in the
+// underlying store is listed and filtered - pages may be shorter than Limit
+// (or empty) even when the returned cursor is not. Names that can't be unblinded
+// (not stored by this Blinded store) are skipped.
+//
+// If Blinder does not implement Unblinder, ErrIrreversibleName is returned.
+func (s *Blinded) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	u, ok := s.Blinder.(Unblinder)
+	if !ok {
+		return nil, "", ErrIrreversibleName
+	}
+
+	l, ok := s.Store.(Lister)
+	if !ok {
+		return nil, "", ErrListUnsupported
+	}
+
+	blinded, cursor, err := l.List(&ListOpts{
+		Limit:  opts.Limit,
+		Cursor: opts.Cursor,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := []string{}
+	for _, b := range blinded {
+		name, err := u.Unblind(b)
+		if err != nil {
+			continue
+		}
+
+		if strings.HasPrefix(name, opts.Prefix) {
+			names = append(names, name)
+		}
+	}
+
+	return names, cursor, nil
+}
+
+// Watch watches the secrets with names starting with prefix, unblinding the
+// name of each changed secret.
+//
+// Blinded names don't share the prefix of the original name, so every change
+// in the underlying store is received and filtered. If Blinder does not
+// implement Unblinder, ErrIrreversibleName is returned.
+func (s *Blinded) Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error) {
+	u, ok := s.Blinder.(Unblinder)
+	if !ok {
+		return nil, ErrIrreversibleName
+	}
+
+	w, ok := s.Store.(Watcher)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+
+	events, err := w.Watch(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return mapWatch(ctx, events, func(blinded string) (string, bool) {
+		name, err := u.Unblind(blinded)
+		if err != nil || !strings.HasPrefix(name, prefix) {
+			return "", false
+		}
+
+		return name, true
+	}), nil
+}
+
+// Unblind returns the original secret name for a blinded name read directly
+// from the underlying store.
+//
+// If Blinder does not implement Unblinder, ErrIrreversibleName is returned.
+func (s *Blinded) Unblind(blinded string) (string, error) {
+	u, ok := s.Blinder.(Unblinder)
+	if !ok {
+		return "", ErrIrreversibleName
+	}
+
+	return u.Unblind(blinded)
+}
+
+// HMACBlinder blinds names using HMAC-SHA256, hex encoding the result.
+//
+// The blinded names cannot be reversed, so stores wrapped with HMACBlinder
+// cannot list the secrets they hold - use SIVBlinder if this is required.
+type HMACBlinder struct {
+	key []byte
+}
+
+// NewHMACBlinder returns an initalised HMACBlinder using key for the HMAC.
+func NewHMACBlinder(key []byte) (*HMACBlinder, error) {
+	if len(key) == 0 {
+		return nil, ErrBlindKeyTooShort
+	}
+
+	return &HMACBlinder{key: key}, nil
+}
+
+// Blind returns the hex encoded HMAC of name.
+func (b *HMACBlinder) Blind(name string) (string, error) {
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(name))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// SIVBlinder blinds names using deterministic, reversible encryption.
+//
+// A synthetic IV is generated by taking the HMAC-SHA256 of the name, which is
+// then used as the IV to encrypt the name with AES-256 in CTR mode. On
+// decryption the IV is recomputed from the recovered name and compared to the
+// stored IV, authenticating the name.
+//
+// Blinded names are base64 (URL safe) encoded, and are roughly 4/3 the length
+// of the original name plus 22 characters - ensure the key column of your
+// store is large enough.
+type SIVBlinder struct {
+	macKey []byte
+	block  cipher.Block
+}
+
+// NewSIVBlinder returns an initalised SIVBlinder, deriving independent
+// encryption and MAC keys from key.
+func NewSIVBlinder(key []byte) (*SIVBlinder, error) {
+	if len(key) == 0 {
+		return nil, ErrBlindKeyTooShort
+	}
+
+	block, err := aes.NewCipher(deriveKey(key, "cryptic-blind-enc"))
+	if err != nil {
+		// This will never happen, deriveKey returns 32 bytes
+		return nil, err
+	}
+
+	return &SIVBlinder{
+		macKey: deriveKey(key, "cryptic-blind-mac"),
+		block:  block,
+	}, nil
+}
+
+// Blind returns name encrypted with the synthetic IV construction.
+func (b *SIVBlinder) Blind(name string) (string, error) {
+	iv := b.iv([]byte(name))
+
+	out := make([]byte, aes.BlockSize+len(name))
+	copy(out, iv)
+
+	stream := cipher.NewCTR(b.block, iv)
+	stream.XORKeyStream(out[aes.BlockSize:], []byte(name))
+
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Unblind decrypts a name blinded by Blind, validating the synthetic IV.
+func (b *SIVBlinder) Unblind(blinded string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(blinded)
+	if err != nil || len(raw) < aes.BlockSize {
+		return "", ErrInvalidName
+	}
+
+	iv := raw[:aes.BlockSize]
+	name := make([]byte, len(raw)-aes.BlockSize)
+
+	stream := cipher.NewCTR(b.block, iv)
+	stream.XORKeyStream(name, raw[aes.BlockSize:])
+
+	// Ensure the name hasn't been tampered with, or blinded with another key
+	if !hmac.Equal(iv, b.iv(name)) {
+		return "", ErrInvalidName
+	}
+
+	return string(name), nil
+}
+
+// iv returns the synthetic IV for name.
+func (b *SIVBlinder) iv(name []byte) []byte {
+	mac := hmac.New(sha256.New, b.macKey)
+	mac.Write(name)
+
+	return mac.Sum(nil)[:aes.BlockSize]
+}
+
+// deriveKey returns a 32 byte key for the given purpose derived from key.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,207 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// TestBlindedPutGet ensures secrets can be fetched by their original name, and
+// the name never reaches the underlying store.
+func TestBlindedPutGet(t *testing.T) {
+	hmacBlinder, _ := NewHMACBlinder([]byte("key"))
+	sivBlinder, _ := NewSIVBlinder([]byte("key"))
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		blinder Blinder
+		pname   string
+		data    *encryptor.EncryptedData
+		// Expected results.
+		wantErr error
+	}{
+		{
+			"HMAC",
+			hmacBlinder,
+			"prod/stripe_live_key",
+			&encryptor.EncryptedData{Ciphertext: []byte("a 🐐")},
+			nil,
+		},
+		{
+			"SIV",
+			sivBlinder,
+			"prod/stripe_live_key",
+			&encryptor.EncryptedData{Ciphertext: []byte("a 🐐")},
+			nil,
+		},
+		{
+			"No name",
+			sivBlinder,
+			"",
+			&encryptor.EncryptedData{},
+			ErrInvalidName,
+		},
+	}
+
+	for _, tt := range tests {
+		mem := NewMemory()
+		s := NewBlinded(mem, tt.blinder)
+
+		if err := s.Put(tt.pname, tt.data); err != tt.wantErr {
+			t.Errorf("%q. Blinded.Put() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if tt.wantErr != nil {
+			continue
+		}
+
+		got, err := s.Get(tt.pname)
+		if err != nil {
+			t.Errorf("%q. Blinded.Get() error = %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.data) {
+			t.Errorf("%q. Blinded.Get() = %v, want %v", tt.name, got, tt.data)
+		}
+
+		if _, ok := mem.secrets[tt.pname]; ok {
+			t.Errorf("%q. Blinded.Put() stored the unblinded name", tt.name)
+		}
+
+		if err := s.Put(tt.pname, tt.data); err != ErrAlreadyExists {
+			t.Errorf("%q. Blinded.Put() duplicate error = %v, want %v", tt.name, err, ErrAlreadyExists)
+		}
+
+		if err := s.Delete(tt.pname); err != nil {
+			t.Errorf("%q. Blinded.Delete() error = %v", tt.name, err)
+		}
+
+		if len(mem.secrets) != 0 {
+			t.Errorf("%q. Blinded.Delete() left %d secrets in store", tt.name, len(mem.secrets))
+		}
+	}
+}
+
+// TestBlindedUnblind ensures only reversible blinders recover the original name,
+// and tampered names are rejected.
+func TestBlindedUnblind(t *testing.T) {
+	hmacBlinder, _ := NewHMACBlinder([]byte("key"))
+	sivBlinder, _ := NewSIVBlinder([]byte("key"))
+	otherBlinder, _ := NewSIVBlinder([]byte("other key"))
+
+	blinded, _ := sivBlinder.Blind("prod/stripe_live_key")
+	tampered := []byte(blinded)
+	tampered[len(tampered)-1] ^= 0x01
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		blinder Blinder
+		blinded string
+		// Expected results.
+		want    string
+		wantErr error
+	}{
+		{
+			"SIV",
+			sivBlinder,
+			blinded,
+			"prod/stripe_live_key",
+			nil,
+		},
+		{
+			"SIV wrong key",
+			otherBlinder,
+			blinded,
+			"",
+			ErrInvalidName,
+		},
+		{
+			"SIV tampered",
+			sivBlinder,
+			string(tampered),
+			"",
+			ErrInvalidName,
+		},
+		{
+			"SIV not base64",
+			sivBlinder,
+			"not base64!",
+			"",
+			ErrInvalidName,
+		},
+		{
+			"SIV too short",
+			sivBlinder,
+			"AAAA",
+			"",
+			ErrInvalidName,
+		},
+		{
+			"HMAC",
+			hmacBlinder,
+			blinded,
+			"",
+			ErrIrreversibleName,
+		},
+	}
+
+	for _, tt := range tests {
+		s := NewBlinded(NewMemory(), tt.blinder)
+
+		got, err := s.Unblind(tt.blinded)
+		if err != tt.wantErr {
+			t.Errorf("%q. Blinded.Unblind() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%q. Blinded.Unblind() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewBlinders(t *testing.T) {
+	if _, err := NewHMACBlinder([]byte{}); err != ErrBlindKeyTooShort {
+		t.Errorf("NewHMACBlinder() error = %v, want %v", err, ErrBlindKeyTooShort)
+	}
+
+	if _, err := NewSIVBlinder([]byte{}); err != ErrBlindKeyTooShort {
+		t.Errorf("NewSIVBlinder() error = %v, want %v", err, ErrBlindKeyTooShort)
+	}
+}
+
+// TestBlindedGetMany ensures secrets are fetched by their original names, and
+// ErrBulkUnsupported is returned without a BulkGetter underlying store.
+func TestBlindedGetMany(t *testing.T) {
+	blinder, _ := NewSIVBlinder([]byte("key"))
+
+	s := NewBlinded(NewMemory(), blinder)
+	s.Put("one", &encryptor.EncryptedData{Ciphertext: []byte("1")})
+	s.Put("two", &encryptor.EncryptedData{Ciphertext: []byte("2")})
+
+	got, err := s.GetMany([]string{"one", "two", "missing"})
+	if err != nil {
+		t.Fatalf("Blinded.GetMany() error = %v", err)
+	}
+
+	want := map[string]*encryptor.EncryptedData{
+		"one": {Ciphertext: []byte("1")},
+		"two": {Ciphertext: []byte("2")},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Blinded.GetMany() = %v, want %v", got, want)
+	}
+
+	s = NewBlinded(struct{ Interface }{NewMemory()}, blinder)
+	if _, err := s.GetMany([]string{"one"}); err != ErrBulkUnsupported {
+		t.Errorf("Blinded.GetMany() without BulkGetter error = %v, want %v", err, ErrBulkUnsupported)
+	}
+}
@@ -0,0 +1,250 @@
+package store
+
+import (
+	"bytes"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultBoltBucket is the bucket secrets are stored in when no namespace is
+// configured.
+const DefaultBoltBucket = "secrets"
+
+// Bolt stores secrets in a bucket of a bbolt database - a single file with ACID
+// transactions, needing no server.
+//
+// Each bucket is independent, so a bucket per namespace keeps namespaces apart
+// without prefixing names (see Namespaced).
+type Bolt struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBolt returns an initalised Bolt store, storing secrets in the named
+// bucket of db. The bucket is created if it doesn't exist.
+func NewBolt(db *bolt.DB, bucket string) (*Bolt, error) {
+	if bucket == "" {
+		return nil, ErrInvalidNamespace
+	}
+
+	s := &Bolt{
+		db:     db,
+		bucket: []byte(bucket),
+	}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.bucket)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Put stores data under the given name, checking the name is unused within the
+// same transaction.
+func (s *Bolt) Put(name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b.Get([]byte(name)) != nil {
+			return ErrAlreadyExists
+		}
+
+		return b.Put([]byte(name), buf)
+	})
+}
+
+// Get fetches the secret stored under name.
+func (s *Bolt) Get(name string) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	d := &encryptor.EncryptedData{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		// The value is only valid within the transaction, but decoding it
+		// makes a copy
+		buf := tx.Bucket(s.bucket).Get([]byte(name))
+		if buf == nil {
+			return ErrNotFound
+		}
+
+		return d.UnmarshalBinary(buf)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// GetMany fetches the named secrets within a single read transaction.
+func (s *Bolt) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	out := map[string]*encryptor.EncryptedData{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+
+		for _, name := range names {
+			if name == "" {
+				return ErrInvalidName
+			}
+
+			buf := b.Get([]byte(name))
+			if buf == nil {
+				continue
+			}
+
+			d := &encryptor.EncryptedData{}
+			if err := d.UnmarshalBinary(buf); err != nil {
+				return err
+			}
+
+			out[name] = d
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// List returns the names of the secrets in the bucket in lexical order. Keys
+// are kept sorted, so only the names in the page are read.
+func (s *Bolt) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	limit := listLimit(opts)
+	names := []string{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(s.bucket).Cursor()
+
+		// Start from the cursor if it's within the prefix, skipping the cursor
+		// itself
+		start := opts.Prefix
+		if opts.Cursor > start {
+			start = opts.Cursor
+		}
+
+		k, _ := c.Seek([]byte(start))
+		if k != nil && string(k) == opts.Cursor {
+			k, _ = c.Next()
+		}
+
+		// Fetch an extra name to find out if there's another page
+		for ; k != nil && len(names) <= limit; k, _ = c.Next() {
+			if !bytes.HasPrefix(k, []byte(opts.Prefix)) {
+				break
+			}
+
+			names = append(names, string(k))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(names) <= limit {
+		return names, "", nil
+	}
+
+	names = names[:limit]
+	return names, names[limit-1], nil
+}
+
+// Delete removes the secret stored under name.
+func (s *Bolt) Delete(name string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b.Get([]byte(name)) == nil {
+			return ErrNotFound
+		}
+
+		return b.Delete([]byte(name))
+	})
+}
+
+// Update replaces the secret stored under name.
+func (s *Bolt) Update(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		if cur == nil {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// Upsert stores data under name, replacing any existing secret.
+func (s *Bolt) Upsert(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return nil
+	})
+}
+
+// CompareAndSwap replaces the secret stored under name if the current
+// cipher-text matches old.
+func (s *Bolt) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return checkSwap(cur, old)
+	})
+}
+
+// swap stores data under name if check returns nil when passed the current
+// secret (or nil if it does not exist), within a single transaction.
+func (s *Bolt) swap(name string, data *encryptor.EncryptedData, check func(cur *encryptor.EncryptedData) error) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+
+		var cur *encryptor.EncryptedData
+		if raw := b.Get([]byte(name)); raw != nil {
+			cur = &encryptor.EncryptedData{}
+			if err := cur.UnmarshalBinary(raw); err != nil {
+				return err
+			}
+		}
+
+		if err := check(cur); err != nil {
+			return err
+		}
+
+		return b.Put([]byte(name), buf)
+	})
+}
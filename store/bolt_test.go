@@ -0,0 +1,143 @@
+package store
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	bolt "go.etcd.io/bbolt"
+)
+
+// TestBoltPutGet ensures the Bolt store behaves the same as Memory.
+func TestBoltPutGet(t *testing.T) {
+	s, cleanup := newTestBolt(t, DefaultBoltBucket)
+	defer cleanup()
+
+	data := &encryptor.EncryptedData{
+		Ciphertext: []byte("ciphertext"),
+		HMAC:       []byte("hmac"),
+		Type:       encryptor.Nop,
+		Context:    map[string]interface{}{},
+	}
+
+	if err := s.Put("", data); err != ErrInvalidName {
+		t.Errorf("Bolt.Put() empty name error = %v, want %v", err, ErrInvalidName)
+	}
+
+	if _, err := s.Get("secret"); err != ErrNotFound {
+		t.Errorf("Bolt.Get() missing error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.Put("secret", data); err != nil {
+		t.Fatalf("Bolt.Put() error = %v", err)
+	}
+
+	if err := s.Put("secret", data); err != ErrAlreadyExists {
+		t.Errorf("Bolt.Put() existing error = %v, want %v", err, ErrAlreadyExists)
+	}
+
+	if got, err := s.Get("secret"); err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("Bolt.Get() = %v, %v, want %v", got, err, data)
+	}
+
+	got, err := s.GetMany([]string{"secret", "missing"})
+	if want := map[string]*encryptor.EncryptedData{"secret": data}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Bolt.GetMany() = %v, %v, want %v", got, err, want)
+	}
+
+	if err := s.Delete("secret"); err != nil {
+		t.Errorf("Bolt.Delete() error = %v", err)
+	}
+
+	if err := s.Delete("secret"); err != ErrNotFound {
+		t.Errorf("Bolt.Delete() missing error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+// TestBoltBuckets ensures secrets in one bucket are not visible from another
+// sharing the same database.
+func TestBoltBuckets(t *testing.T) {
+	prod, cleanup := newTestBolt(t, "prod")
+	defer cleanup()
+
+	dev, err := NewBolt(prod.db, "dev")
+	if err != nil {
+		t.Fatalf("NewBolt() error = %v", err)
+	}
+
+	if _, err := NewBolt(prod.db, ""); err != ErrInvalidNamespace {
+		t.Errorf("NewBolt() empty bucket error = %v, want %v", err, ErrInvalidNamespace)
+	}
+
+	data := &encryptor.EncryptedData{Ciphertext: []byte("prod")}
+	if err := prod.Put("secret", data); err != nil {
+		t.Fatalf("Bolt.Put() error = %v", err)
+	}
+
+	if _, err := dev.Get("secret"); err != ErrNotFound {
+		t.Errorf("Bolt.Get() other bucket error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := dev.Put("secret", &encryptor.EncryptedData{Ciphertext: []byte("dev")}); err != nil {
+		t.Errorf("Bolt.Put() other bucket error = %v", err)
+	}
+
+	if names, _, err := dev.List(nil); err != nil || !reflect.DeepEqual(names, []string{"secret"}) {
+		t.Errorf("Bolt.List() = %v, %v, want [secret]", names, err)
+	}
+
+	if got, err := prod.Get("secret"); err != nil || !bytes.Equal(got.Ciphertext, data.Ciphertext) {
+		t.Errorf("Bolt.Get() = %v, %v, want %v", got, err, data)
+	}
+}
+
+// TestBoltConcurrent ensures only one of many concurrent Put and Delete calls
+// for the same name succeeds.
+func TestBoltConcurrent(t *testing.T) {
+	s, cleanup := newTestBolt(t, DefaultBoltBucket)
+	defer cleanup()
+
+	if got := hammer(func(i int) error {
+		return s.Put("secret", &encryptor.EncryptedData{Ciphertext: []byte{byte(i)}})
+	}); got != 1 {
+		t.Errorf("Bolt.Put() succeeded %d times, want 1", got)
+	}
+
+	if got := hammer(func(i int) error {
+		return s.Delete("secret")
+	}); got != 1 {
+		t.Errorf("Bolt.Delete() succeeded %d times, want 1", got)
+	}
+}
+
+// newTestBolt returns a Bolt store using bucket of a temporary database, and a
+// func closing and removing the database.
+func newTestBolt(t *testing.T, bucket string) (*Bolt, func()) {
+	dir, err := ioutil.TempDir("", "cryptic")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, "cryptic.db"), 0600, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Failed to open bolt db: %s", err)
+	}
+
+	cleanup := func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+
+	s, err := NewBolt(db, bucket)
+	if err != nil {
+		cleanup()
+		t.Fatalf("Failed to initialise Bolt store: %s", err)
+	}
+
+	return s, cleanup
+}
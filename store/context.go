@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// WithContext returns s as a ContextInterface.
+//
+// If s does not implement ContextInterface, each call is run in a separate
+// goroutine and abandoned if ctx is cancelled - the underlying operation is not
+// interrupted, and may still complete after the call has returned.
+func WithContext(s Interface) ContextInterface {
+	if c, ok := s.(ContextInterface); ok {
+		return c
+	}
+
+	return contextAdapter{s}
+}
+
+// contextAdapter implements ContextInterface for a store that doesn't support
+// contexts.
+type contextAdapter struct {
+	s Interface
+}
+
+// PutContext calls Put, returning early if ctx is cancelled.
+func (a contextAdapter) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	return RunContext(ctx, func() error {
+		return a.s.Put(name, data)
+	})
+}
+
+// GetContext calls Get, returning early if ctx is cancelled.
+func (a contextAdapter) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	var data *encryptor.EncryptedData
+
+	err := RunContext(ctx, func() error {
+		var err error
+		data, err = a.s.Get(name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// DeleteContext calls Delete, returning early if ctx is cancelled.
+func (a contextAdapter) DeleteContext(ctx context.Context, name string) error {
+	return RunContext(ctx, func() error {
+		return a.s.Delete(name)
+	})
+}
+
+// RunContext calls fn in a new goroutine, returning the result of fn or the
+// context error if ctx is cancelled first. It allows store methods without a
+// context-aware variant (such as Versioner.PutVersion) to be abandoned, but as
+// with WithContext the underlying operation is not interrupted.
+//
+// Any values set by fn must only be read if RunContext returns nil.
+func RunContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
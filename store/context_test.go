@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// blockingStore never returns from Get until unblock is closed.
+type blockingStore struct {
+	Interface
+	unblock chan struct{}
+}
+
+func (b blockingStore) Get(name string) (*encryptor.EncryptedData, error) {
+	<-b.unblock
+	return b.Interface.Get(name)
+}
+
+// TestWithContext ensures the adapter passes calls through to stores without
+// context support, and abandons them when the context is cancelled.
+func TestWithContext(t *testing.T) {
+	mem := NewMemory()
+	mem.Put("secret", &encryptor.EncryptedData{Ciphertext: []byte("a 🐐")})
+
+	blocked := blockingStore{mem, make(chan struct{})}
+	defer close(blocked.unblock)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+		ctx   context.Context
+		// Expected results.
+		want    *encryptor.EncryptedData
+		wantErr error
+	}{
+		{
+			"Passed through",
+			struct{ Interface }{mem},
+			context.Background(),
+			&encryptor.EncryptedData{Ciphertext: []byte("a 🐐")},
+			nil,
+		},
+		{
+			"Store errors passed up",
+			struct{ Interface }{NewMemory()},
+			context.Background(),
+			nil,
+			ErrNotFound,
+		},
+		{
+			"Already cancelled",
+			struct{ Interface }{mem},
+			cancelled,
+			nil,
+			context.Canceled,
+		},
+		{
+			"Timeout while blocked",
+			blocked,
+			expired,
+			nil,
+			context.DeadlineExceeded,
+		},
+		{
+			"Memory cancelled",
+			mem,
+			cancelled,
+			nil,
+			context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := WithContext(tt.store).GetContext(tt.ctx, "secret")
+		if err != tt.wantErr {
+			t.Errorf("%q. GetContext() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q. GetContext() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestBlindedContext ensures the context reaches the underlying store.
+func TestBlindedContext(t *testing.T) {
+	blinder, _ := NewHMACBlinder([]byte("key"))
+	s := NewBlinded(NewMemory(), blinder)
+
+	ctx := context.Background()
+	data := &encryptor.EncryptedData{Ciphertext: []byte("a 🐐")}
+
+	if err := s.PutContext(ctx, "secret", data); err != nil {
+		t.Fatalf("Blinded.PutContext() error = %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := s.GetContext(cancelled, "secret"); err != context.Canceled {
+		t.Errorf("Blinded.GetContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	got, err := s.GetContext(ctx, "secret")
+	if err != nil {
+		t.Fatalf("Blinded.GetContext() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, data) {
+		t.Errorf("Blinded.GetContext() = %v, want %v", got, data)
+	}
+
+	if err := s.DeleteContext(cancelled, "secret"); err != context.Canceled {
+		t.Errorf("Blinded.DeleteContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	if err := s.DeleteContext(ctx, "secret"); err != nil {
+		t.Errorf("Blinded.DeleteContext() error = %v", err)
+	}
+}
+
+// TestDbContext ensures a cancelled context aborts the query.
+func TestDbContext(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Errorf("Failed to set up sqlite db: %s", err)
+		return
+	}
+
+	if _, err := db.Exec(tableSQL); err != nil {
+		t.Errorf("Failed to create db table: %s", err)
+		return
+	}
+
+	s, err := NewDB(db, nil)
+	if err != nil {
+		t.Errorf("Failed to initialise DB store: %s", err)
+		return
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := &encryptor.EncryptedData{Ciphertext: []byte("a 🐐")}
+
+	if err := s.PutContext(cancelled, "secret", data); err != context.Canceled {
+		t.Errorf("DB.PutContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	if _, err := s.Get("secret"); err != ErrNotFound {
+		t.Errorf("DB.Get() after cancelled put error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.PutContext(context.Background(), "secret", data); err != nil {
+		t.Errorf("DB.PutContext() error = %v", err)
+	}
+
+	if _, err := s.GetContext(cancelled, "secret"); err != context.Canceled {
+		t.Errorf("DB.GetContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	if err := s.DeleteContext(cancelled, "secret"); err != context.Canceled {
+		t.Errorf("DB.DeleteContext() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -1,24 +1,57 @@
 package store
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/domodwyer/cryptic/encryptor"
 )
 
 // DB stores secrets in a database.
 //
-// It is expected that the key column has a UNIQUE constraint. Unlike most
-// stores, DB does not return ErrAlreadyExists when attempting to Put() a secret
-// already in the store, as each database driver returns a different error -
-// instead the driver specific error is returned.
+// It is expected that the key column has a UNIQUE constraint. Driver errors are
+// translated to store errors by the Dialect, so attempting to Put() a secret
+// already in the store returns ErrAlreadyExists as with any other store, and a
+// missing table returns ErrMissingTable.
+//
+// Queries are written for the Dialect in DBOpts - MySQL, Postgres and SQLite
+// are supported. The schema can be created and kept up to date with Migrate.
 type DB struct {
-	getStmt *sql.Stmt
-	putStmt *sql.Stmt
-	delStmt *sql.Stmt
+	db       *sql.DB
+	dialect  Dialect
+	names    dbNames
+	table    string
+	key      string
+	value    string
+	history  string
+	expires  string
+	metadata string
+	version  string
+	getStmt  *sql.Stmt
+	putStmt  *sql.Stmt
+	delStmt  *sql.Stmt
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
 }
 
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// dbBatchSize is the maximum number of secrets fetched by a single query in
+// GetMany, keeping the number of placeholders within driver limits.
+const dbBatchSize = 500
+
 // DBOpts allows the user to use a different database schema than the defaults.
 //
 // It is expected that the DBOpts values are from trusted input (free from SQL
@@ -27,31 +60,130 @@ type DBOpts struct {
 	Table string
 	Key   string
 	Value string
+
+	// Dialect is the SQL dialect of the database, defaulting to MySQL.
+	Dialect Dialect
+
+	// VersionTable enables versioned secrets, holding the history of each
+	// secret - see Versioner. Versioning is disabled if VersionTable is empty.
+	VersionTable string
+
+	// ExpiresColumn enables expiring secrets - see Expirer. The column holds
+	// the unix time each secret expires at, or NULL if it never expires.
+	// Expiry is disabled if ExpiresColumn is empty.
+	ExpiresColumn string
+
+	// MetadataColumn enables secret metadata - see Describer. The column holds
+	// the JSON encoded Metadata, or NULL if the secret has none. Metadata is
+	// disabled if MetadataColumn is empty.
+	MetadataColumn string
+
+	// Migrate creates or updates the schema when the store is initalised - see
+	// DB.Migrate.
+	Migrate bool
+}
+
+// dbNames holds the unquoted identifiers, needed to inspect the schema.
+type dbNames struct {
+	table    string
+	key      string
+	expires  string
+	metadata string
 }
 
 // NewDB returns an initalised DB store
 func NewDB(db *sql.DB, opts *DBOpts) (*DB, error) {
 	t, k, v := parseOpts(opts)
+	d := dialect(opts)
 
-	getSQL := fmt.Sprintf("SELECT `%s` FROM `%s` WHERE `%s`= ? LIMIT 1", v, t, k)
-	get, err := db.Prepare(getSQL)
-	if err != nil {
-		return nil, err
+	// Identifiers are quoted once here, and used as-is in every query
+	s := &DB{
+		db:       db,
+		dialect:  d,
+		names:    dbNames{t, k, expiresColumn(opts), metadataColumn(opts)},
+		table:    d.Quote(t),
+		key:      d.Quote(k),
+		value:    d.Quote(v),
+		history:  quoteOptional(d, versionTable(opts)),
+		expires:  quoteOptional(d, expiresColumn(opts)),
+		metadata: quoteOptional(d, metadataColumn(opts)),
+		version:  d.Quote("version"),
 	}
 
-	putSQL := fmt.Sprintf("INSERT INTO `%s` (`%s`, `%s`) VALUES (?, ?)", t, k, v)
-	put, err := db.Prepare(putSQL)
-	if err != nil {
-		return nil, err
+	// The tables must exist before any statements can be prepared
+	if opts != nil && opts.Migrate {
+		if _, err := s.Migrate(); err != nil {
+			return nil, err
+		}
 	}
 
-	delSQL := fmt.Sprintf("DELETE FROM `%s` WHERE `%s` = ?", t, k)
-	del, err := db.Prepare(delSQL)
-	if err != nil {
-		return nil, err
+	getSQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ? LIMIT 1", s.value, s.table, s.key)
+	if s.expires != "" {
+		getSQL = fmt.Sprintf(
+			"SELECT %s FROM %s WHERE %s = ? AND (%s IS NULL OR %s > ?) LIMIT 1",
+			s.value, s.table, s.key, s.expires, s.expires,
+		)
+	}
+
+	var err error
+	if s.getStmt, err = db.Prepare(s.rebind(getSQL)); err != nil {
+		return nil, s.translate(err)
+	}
+
+	putSQL := fmt.Sprintf("INSERT INTO %s (%s, %s) VALUES (?, ?)", s.table, s.key, s.value)
+	if s.putStmt, err = db.Prepare(s.rebind(putSQL)); err != nil {
+		return nil, s.translate(err)
+	}
+
+	delSQL := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", s.table, s.key)
+	if s.delStmt, err = db.Prepare(s.rebind(delSQL)); err != nil {
+		return nil, s.translate(err)
+	}
+
+	return s, nil
+}
+
+// dialect returns the configured Dialect, defaulting to MySQL.
+func dialect(opts *DBOpts) Dialect {
+	if opts == nil || opts.Dialect == nil {
+		return MySQL
+	}
+
+	return opts.Dialect
+}
+
+// quoteOptional quotes identifier using d, unless identifier is empty.
+func quoteOptional(d Dialect, identifier string) string {
+	if identifier == "" {
+		return ""
+	}
+
+	return d.Quote(identifier)
+}
+
+// translate returns the store error matching the driver error err, if any -
+// see Dialect.TranslateError.
+func (s *DB) translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return s.dialect.TranslateError(err)
+}
+
+// conflict returns ErrConflict in place of ErrAlreadyExists, for writes that
+// lose a race with a concurrent writer of the same secret.
+func conflict(err error) error {
+	if err == ErrAlreadyExists {
+		return ErrConflict
 	}
 
-	return &DB{get, put, del}, nil
+	return err
+}
+
+// rebind replaces the "?" placeholders in query with those of the dialect.
+func (s *DB) rebind(query string) string {
+	return rebind(s.dialect, query)
 }
 
 // parseOpts sets sensible defaults, and returns any user-set DB config.
@@ -79,9 +211,42 @@ func parseOpts(opts *DBOpts) (string, string, string) {
 	return t, k, v
 }
 
+// versionTable returns the configured history table, if any.
+func versionTable(opts *DBOpts) string {
+	if opts == nil {
+		return ""
+	}
+
+	return opts.VersionTable
+}
+
+// metadataColumn returns the configured metadata column, if any.
+func metadataColumn(opts *DBOpts) string {
+	if opts == nil {
+		return ""
+	}
+
+	return opts.MetadataColumn
+}
+
+// expiresColumn returns the configured expiry column, if any.
+func expiresColumn(opts *DBOpts) string {
+	if opts == nil {
+		return ""
+	}
+
+	return opts.ExpiresColumn
+}
+
 // Put encodes data using binary gobs and stores the result in the database
 // using name as the key.
 func (s *DB) Put(name string, data *encryptor.EncryptedData) error {
+	return s.PutContext(context.Background(), name, data)
+}
+
+// PutContext is the same as Put, but the query is cancelled if ctx is
+// cancelled before it completes.
+func (s *DB) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
 	if name == "" {
 		return ErrInvalidName
 	}
@@ -91,15 +256,117 @@ func (s *DB) Put(name string, data *encryptor.EncryptedData) error {
 		return err
 	}
 
-	if _, err := s.putStmt.Exec(name, buf); err != nil {
+	if err := s.reap(ctx, s.db, name); err != nil {
 		return err
 	}
 
+	if _, err := s.putStmt.ExecContext(ctx, name, buf); err != nil {
+		return s.translate(err)
+	}
+
 	return nil
 }
 
+// PutWithTTL stores data using name as the key, setting the expiry column so
+// it is no longer returned once ttl has passed.
+//
+// Expired secrets are left in the table until they're replaced or removed by
+// Purge.
+func (s *DB) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	if err := checkTTL(ttl); err != nil {
+		return err
+	}
+
+	if s.expires == "" {
+		return ErrExpiryUnsupported
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if err := s.reap(ctx, s.db, name); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)",
+		s.table, s.key, s.value, s.expires,
+	)
+
+	_, err = s.db.ExecContext(ctx, s.rebind(query), name, buf, timeNow().Add(ttl).Unix())
+	return s.translate(err)
+}
+
+// Purge removes all expired secrets from the database, returning the number
+// removed.
+func (s *DB) Purge() (int, error) {
+	if s.expires == "" {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s <= ?", s.table, s.expires)
+	res, err := s.db.Exec(s.rebind(query), timeNow().Unix())
+	if err != nil {
+		return 0, s.translate(err)
+	}
+
+	i, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(i), nil
+}
+
+// reap removes name if it has expired, so the name can be used again.
+//
+// Secrets with a history never have an expiry, so there's no history to
+// remove.
+func (s *DB) reap(ctx context.Context, e execer, name string) error {
+	if s.expires == "" {
+		return nil
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s <= ?", s.table, s.key, s.expires)
+	_, err := e.ExecContext(ctx, s.rebind(query), name, timeNow().Unix())
+	return s.translate(err)
+}
+
+// getArgs returns the arguments for getStmt.
+func (s *DB) getArgs(name string) []interface{} {
+	if s.expires == "" {
+		return []interface{}{name}
+	}
+
+	return []interface{}{name, timeNow().Unix()}
+}
+
+// notExpired returns a WHERE condition excluding expired secrets, and its
+// arguments, or an empty string if expiry is disabled.
+func (s *DB) notExpired() (string, []interface{}) {
+	if s.expires == "" {
+		return "", nil
+	}
+
+	cond := fmt.Sprintf("(%s IS NULL OR %s > ?)", s.expires, s.expires)
+	return cond, []interface{}{timeNow().Unix()}
+}
+
 // Get fetches the secret stored under name.
 func (s *DB) Get(name string) (*encryptor.EncryptedData, error) {
+	return s.GetContext(context.Background(), name)
+}
+
+// GetContext is the same as Get, but the query is cancelled if ctx is
+// cancelled before it completes.
+func (s *DB) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
 	if name == "" {
 		return nil, ErrInvalidName
 	}
@@ -107,7 +374,7 @@ func (s *DB) Get(name string) (*encryptor.EncryptedData, error) {
 	data := []byte{}
 
 	// Get the data, translate a ErrNoRows into our ErrNotFound
-	err := s.getStmt.QueryRow(name).Scan(&data)
+	err := s.getStmt.QueryRowContext(ctx, s.getArgs(name)...).Scan(&data)
 
 	switch err {
 	case nil:
@@ -117,7 +384,7 @@ func (s *DB) Get(name string) (*encryptor.EncryptedData, error) {
 		return nil, ErrNotFound
 
 	default:
-		return nil, err
+		return nil, s.translate(err)
 	}
 
 	d := encryptor.EncryptedData{}
@@ -128,17 +395,201 @@ func (s *DB) Get(name string) (*encryptor.EncryptedData, error) {
 	return &d, nil
 }
 
+// GetMany fetches the named secrets using as few queries as possible.
+func (s *DB) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	out := map[string]*encryptor.EncryptedData{}
+
+	for start := 0; start < len(names); start += dbBatchSize {
+		end := start + dbBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		if err := s.getBatch(names[start:end], out); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// getBatch fetches the named secrets with a single query, adding them to out.
+func (s *DB) getBatch(names []string, out map[string]*encryptor.EncryptedData) error {
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		if name == "" {
+			return ErrInvalidName
+		}
+
+		args[i] = name
+	}
+
+	placeholders := strings.Repeat("?, ", len(names))
+	placeholders = placeholders[:len(placeholders)-2]
+
+	query := fmt.Sprintf(
+		"SELECT %s, %s FROM %s WHERE %s IN (%s)",
+		s.key, s.value, s.table, s.key, placeholders,
+	)
+
+	if cond, condArgs := s.notExpired(); cond != "" {
+		query += " AND " + cond
+		args = append(args, condArgs...)
+	}
+
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return s.translate(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var data []byte
+
+		if err := rows.Scan(&name, &data); err != nil {
+			return s.translate(err)
+		}
+
+		d := &encryptor.EncryptedData{}
+		if err := d.UnmarshalBinary(data); err != nil {
+			return err
+		}
+
+		out[name] = d
+	}
+
+	return s.translate(rows.Err())
+}
+
+// List returns the names of the secrets in the database in KeyColumn order,
+// using keyset pagination so each page is an indexed range scan.
+//
+// The range only narrows the scan - case-insensitive collations (such as the
+// MySQL default) match more than the prefix, so every name is checked with
+// strings.HasPrefix and further rows are read until the page is full.
+//
+// The returned cursor is the last name in the page.
+func (s *DB) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	limit := listLimit(opts)
+
+	names := []string{}
+	cursor := opts.Cursor
+	for {
+		// Fetch an extra row to find out if there's another page
+		keys, err := s.listKeys(opts.Prefix, cursor, limit+1)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, k := range keys {
+			if strings.HasPrefix(k, opts.Prefix) {
+				names = append(names, k)
+			}
+		}
+
+		if len(names) > limit || len(keys) <= limit {
+			break
+		}
+
+		cursor = keys[len(keys)-1]
+	}
+
+	if len(names) <= limit {
+		return names, "", nil
+	}
+
+	names = names[:limit]
+	return names, names[limit-1], nil
+}
+
+// listKeys returns up to limit keys after cursor in KeyColumn order, within
+// the range of keys that may start with prefix.
+func (s *DB) listKeys(prefix, cursor string, limit int) ([]string, error) {
+	where := []string{}
+	args := []interface{}{}
+
+	if cursor != "" {
+		where = append(where, fmt.Sprintf("%s > ?", s.key))
+		args = append(args, cursor)
+	}
+
+	if cond, condArgs := s.notExpired(); cond != "" {
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+
+	if prefix != "" {
+		where = append(where, fmt.Sprintf("%s >= ?", s.key))
+		args = append(args, prefix)
+
+		// Incrementing the last byte may not leave valid UTF-8, which Postgres
+		// rejects - the range is only a hint, so leave it open instead
+		if end := prefixEnd(prefix); end != "" && utf8.ValidString(end) {
+			where = append(where, fmt.Sprintf("%s < ?", s.key))
+			args = append(args, end)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", s.key, s.table)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", s.key, limit)
+
+	rows, err := s.db.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, s.translate(err)
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, s.translate(err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, s.translate(rows.Err())
+}
+
 // Delete removes a secret from the database.
 func (s *DB) Delete(name string) error {
+	return s.DeleteContext(context.Background(), name)
+}
+
+// DeleteContext is the same as Delete, but the query is cancelled if ctx is
+// cancelled before it completes.
+func (s *DB) DeleteContext(ctx context.Context, name string) error {
 	if name == "" {
 		return ErrInvalidName
 	}
 
-	res, err := s.delStmt.Exec(name)
-	if err != nil {
+	// An expired secret no longer exists
+	if err := s.reap(ctx, s.db, name); err != nil {
 		return err
 	}
 
+	res, err := s.delStmt.ExecContext(ctx, name)
+	if err != nil {
+		return s.translate(err)
+	}
+
+	if s.history != "" {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", s.history, s.key)
+		if _, err := s.db.ExecContext(ctx, s.rebind(query), name); err != nil {
+			return s.translate(err)
+		}
+	}
+
 	i, err := res.RowsAffected()
 	if err != nil {
 		return err
@@ -150,3 +601,520 @@ func (s *DB) Delete(name string) error {
 
 	return nil
 }
+
+// PutMetadata stores m as the metadata of the secret stored under name.
+func (s *DB) PutMetadata(name string, m *Metadata) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	if s.metadata == "" {
+		return ErrMetadataUnsupported
+	}
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", s.table, s.metadata, s.key)
+	args := []interface{}{string(buf), name}
+
+	if cond, condArgs := s.notExpired(); cond != "" {
+		query += " AND " + cond
+		args = append(args, condArgs...)
+	}
+
+	res, err := s.db.Exec(s.rebind(query), args...)
+	if err != nil {
+		return s.translate(err)
+	}
+
+	i, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// MySQL reports no affected rows when the value is unchanged, so check the
+	// secret really is missing
+	if i < 1 {
+		if _, err := s.Get(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetMetadata fetches the metadata of the secret stored under name.
+func (s *DB) GetMetadata(name string) (*Metadata, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	if s.metadata == "" {
+		return nil, ErrMetadataUnsupported
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", s.metadata, s.table, s.key)
+	args := []interface{}{name}
+
+	if cond, condArgs := s.notExpired(); cond != "" {
+		query += " AND " + cond
+		args = append(args, condArgs...)
+	}
+
+	var buf sql.NullString
+	switch err := s.db.QueryRow(s.rebind(query), args...).Scan(&buf); err {
+	case nil:
+		break
+
+	case sql.ErrNoRows:
+		return nil, ErrNotFound
+
+	default:
+		return nil, s.translate(err)
+	}
+
+	if !buf.Valid {
+		return nil, ErrNoMetadata
+	}
+
+	m := &Metadata{}
+	if err := json.Unmarshal([]byte(buf.String), m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// PutVersion stores data as the next version of name within a transaction,
+// updating the secrets table to hold the new version.
+//
+// The history table must have a primary key (or UNIQUE constraint) over the key
+// and version columns, so concurrent writers can never create the same
+// version - the loser receives ErrConflict.
+func (s *DB) PutVersion(name string, data *encryptor.EncryptedData) (int, error) {
+	return s.putVersion(name, data, 0)
+}
+
+// CompareAndSwapVersion stores data as the next version of name, if the latest
+// version is version.
+func (s *DB) CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error) {
+	if version < 1 {
+		return 0, ErrConflict
+	}
+
+	return s.putVersion(name, data, version)
+}
+
+// putVersion stores data as the next version of name. If expect is non-zero,
+// ErrConflict is returned unless the latest version is expect.
+func (s *DB) putVersion(name string, data *encryptor.EncryptedData, expect int) (int, error) {
+	if name == "" {
+		return 0, ErrInvalidName
+	}
+
+	if s.history == "" {
+		return 0, ErrVersioningUnsupported
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, s.translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := s.reap(context.Background(), tx, name); err != nil {
+		return 0, err
+	}
+
+	latest := sql.NullInt64{}
+	query := fmt.Sprintf("SELECT MAX(%s) FROM %s WHERE %s = ?", s.version, s.history, s.key)
+	if err := tx.QueryRow(s.rebind(query), name).Scan(&latest); err != nil {
+		return 0, s.translate(err)
+	}
+
+	version := int(latest.Int64)
+	exists := latest.Valid
+
+	// Secrets stored with Put have no history - record the existing value as
+	// version 1 first
+	if !exists {
+		var first []byte
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", s.value, s.table, s.key)
+		switch err := tx.QueryRow(s.rebind(query), name).Scan(&first); err {
+		case nil:
+			if err := s.insertVersion(tx, name, 1, first); err != nil {
+				return 0, conflict(err)
+			}
+
+			version = 1
+			exists = true
+
+		case sql.ErrNoRows:
+			break
+
+		default:
+			return 0, s.translate(err)
+		}
+	}
+
+	if expect > 0 {
+		if !exists {
+			return 0, ErrNotFound
+		}
+
+		if version != expect {
+			return 0, ErrConflict
+		}
+	}
+
+	version++
+	if err := s.insertVersion(tx, name, version, buf); err != nil {
+		return 0, conflict(err)
+	}
+
+	if exists {
+		query = fmt.Sprintf("UPDATE %s SET %s = ?%s WHERE %s = ?", s.table, s.value, s.clearExpiry(), s.key)
+		_, err = tx.Exec(s.rebind(query), buf, name)
+	} else {
+		_, err = tx.Stmt(s.putStmt).Exec(name, buf)
+	}
+
+	if err != nil {
+		return 0, conflict(s.translate(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, conflict(s.translate(err))
+	}
+
+	return version, nil
+}
+
+// Update replaces the secret stored under name.
+func (s *DB) Update(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		if cur == nil {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// Upsert stores data under name, replacing any existing secret.
+//
+// Unless the secret has to be recorded in the version history, a single upsert
+// statement is used so concurrent writers of a new secret can't collide.
+func (s *DB) Upsert(name string, data *encryptor.EncryptedData) error {
+	if s.history != "" {
+		return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+			return nil
+		})
+	}
+
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if err := s.reap(ctx, s.db, name); err != nil {
+		return err
+	}
+
+	columns := []string{s.value}
+	args := []interface{}{name, buf}
+
+	// Replacing a secret removes any expiry
+	if s.expires != "" {
+		columns = append(columns, s.expires)
+		args = append(args, nil)
+	}
+
+	query := s.dialect.Upsert(s.table, s.key, columns...)
+	_, err = s.db.ExecContext(ctx, s.rebind(query), args...)
+	return s.translate(err)
+}
+
+// CompareAndSwap replaces the secret stored under name if the current
+// cipher-text matches old.
+func (s *DB) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return checkSwap(cur, old)
+	})
+}
+
+// swap stores data under name if check returns nil when passed the current
+// secret (or nil if it does not exist).
+//
+// The row is only updated if it still holds the value passed to check, so a
+// concurrent change results in ErrConflict rather than being overwritten.
+func (s *DB) swap(name string, data *encryptor.EncryptedData, check func(cur *encryptor.EncryptedData) error) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return s.translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := s.reap(context.Background(), tx, name); err != nil {
+		return err
+	}
+
+	var raw []byte
+	var cur *encryptor.EncryptedData
+
+	switch err := tx.Stmt(s.getStmt).QueryRow(s.getArgs(name)...).Scan(&raw); err {
+	case nil:
+		cur = &encryptor.EncryptedData{}
+		if err := cur.UnmarshalBinary(raw); err != nil {
+			return err
+		}
+
+	case sql.ErrNoRows:
+		break
+
+	default:
+		return s.translate(err)
+	}
+
+	if err := check(cur); err != nil {
+		return err
+	}
+
+	// A concurrent writer may create the secret first
+	if cur == nil {
+		if _, err := tx.Stmt(s.putStmt).Exec(name, buf); err != nil {
+			return conflict(s.translate(err))
+		}
+
+		return conflict(s.translate(tx.Commit()))
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s = ?%s WHERE %s = ? AND %s = ?",
+		s.table, s.value, s.clearExpiry(), s.key, s.value,
+	)
+
+	res, err := tx.Exec(s.rebind(query), buf, name, raw)
+	if err != nil {
+		return s.translate(err)
+	}
+
+	i, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// MySQL reports no affected rows when the value is unchanged
+	if i < 1 && !bytes.Equal(buf, raw) {
+		return ErrConflict
+	}
+
+	// Record the new value as the next version if name has a history
+	if s.history != "" {
+		versions, err := s.versionsTx(tx, name)
+		if err != nil {
+			return err
+		}
+
+		if len(versions) > 0 {
+			latest := versions[len(versions)-1]
+			if err := s.insertVersion(tx, name, latest+1, buf); err != nil {
+				return conflict(err)
+			}
+		}
+	}
+
+	return conflict(s.translate(tx.Commit()))
+}
+
+// clearExpiry returns the SET clause to remove the expiry of a replaced secret,
+// or an empty string if expiry is disabled.
+func (s *DB) clearExpiry() string {
+	if s.expires == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(", %s = NULL", s.expires)
+}
+
+// insertVersion adds a row to the history table.
+func (s *DB) insertVersion(tx *sql.Tx, name string, version int, data []byte) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s, %s, %s) VALUES (?, ?, ?)",
+		s.history, s.key, s.version, s.value,
+	)
+
+	_, err := tx.Exec(s.rebind(query), name, version, data)
+	return s.translate(err)
+}
+
+// GetVersion fetches the given version of name.
+func (s *DB) GetVersion(name string, version int) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	if version < 1 {
+		return nil, ErrInvalidVersion
+	}
+
+	if s.history == "" {
+		return nil, ErrVersioningUnsupported
+	}
+
+	data := []byte{}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ? AND %s = ?",
+		s.value, s.history, s.key, s.version,
+	)
+
+	err := s.db.QueryRow(s.rebind(query), name, version).Scan(&data)
+	switch err {
+	case nil:
+		break
+
+	case sql.ErrNoRows:
+		// Secrets stored with Put are version 1, and have no history
+		if version != 1 {
+			return nil, ErrNotFound
+		}
+
+		versions, err := s.versions(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(versions) > 0 {
+			return nil, ErrNotFound
+		}
+
+		return s.Get(name)
+
+	default:
+		return nil, s.translate(err)
+	}
+
+	d := encryptor.EncryptedData{}
+	if err := d.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// Versions returns the version numbers held for name.
+func (s *DB) Versions(name string) ([]int, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	if s.history == "" {
+		return nil, ErrVersioningUnsupported
+	}
+
+	versions, err := s.versions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) > 0 {
+		return versions, nil
+	}
+
+	// No history, but the secret may have been stored with Put
+	if _, err := s.Get(name); err != nil {
+		return nil, err
+	}
+
+	return []int{1}, nil
+}
+
+// Prune removes all but the keep most recent versions of name.
+func (s *DB) Prune(name string, keep int) (int, error) {
+	if name == "" {
+		return 0, ErrInvalidName
+	}
+
+	if keep < 1 {
+		return 0, ErrInvalidVersion
+	}
+
+	versions, err := s.Versions(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(versions) <= keep {
+		return 0, nil
+	}
+
+	cutoff := versions[len(versions)-keep-1]
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s <= ?", s.history, s.key, s.version)
+	res, err := s.db.Exec(s.rebind(query), name, cutoff)
+	if err != nil {
+		return 0, s.translate(err)
+	}
+
+	i, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(i), nil
+}
+
+// versions returns the version numbers in the history table for name, in
+// ascending order.
+func (s *DB) versions(name string) ([]int, error) {
+	return s.versionsTx(s.db, name)
+}
+
+// versionsTx is the same as versions, using q to run the query.
+func (s *DB) versionsTx(q querier, name string) ([]int, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ? ORDER BY %s",
+		s.version, s.history, s.key, s.version,
+	)
+
+	rows, err := q.Query(s.rebind(query), name)
+	if err != nil {
+		return nil, s.translate(err)
+	}
+	defer rows.Close()
+
+	versions := []int{}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, s.translate(err)
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions, s.translate(rows.Err())
+}
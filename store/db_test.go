@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -320,3 +321,63 @@ func TestDbPut(t *testing.T) {
 		}
 	}
 }
+
+// TestDbGetMany ensures secrets are fetched across multiple batches, and missing
+// secrets are omitted.
+func TestDbGetMany(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to set up sqlite db: %s", err)
+	}
+
+	if _, err := db.Exec(tableSQL); err != nil {
+		t.Fatalf("Failed to create db table: %s", err)
+	}
+
+	s, err := NewDB(db, nil)
+	if err != nil {
+		t.Fatalf("NewDB() err = %v", err)
+	}
+
+	names := []string{"missing"}
+	for i := 0; i < dbBatchSize+10; i++ {
+		name := fmt.Sprintf("secret%d", i)
+		names = append(names, name)
+
+		if err := s.Put(name, &encryptor.EncryptedData{Ciphertext: []byte(name)}); err != nil {
+			t.Fatalf("DB.Put() err = %v", err)
+		}
+	}
+
+	got, err := s.GetMany(names)
+	if err != nil {
+		t.Fatalf("DB.GetMany() err = %v", err)
+	}
+
+	if len(got) != len(names)-1 {
+		t.Errorf("DB.GetMany() returned %d secrets, want %d", len(got), len(names)-1)
+	}
+
+	for _, name := range names[1:] {
+		if d, ok := got[name]; !ok || string(d.Ciphertext) != name {
+			t.Errorf("DB.GetMany() [%s] = %v, want ciphertext %q", name, d, name)
+		}
+	}
+
+	if _, err := s.GetMany([]string{"secret1", ""}); err != ErrInvalidName {
+		t.Errorf("DB.GetMany() error = %v, want %v", err, ErrInvalidName)
+	}
+}
+
+// newTestDB returns a DB store using opts, backed by an in-memory sqlite DB
+// with the secrets table and each of setup.
+func newTestDB(t *testing.T, opts DBOpts, setup ...string) *DB {
+	opts.Dialect = SQLite
+
+	s, err := NewDB(newSQLiteDB(t, append([]string{tableSQL}, setup...)...), &opts)
+	if err != nil {
+		t.Fatalf("Failed to initialise DB store: %s", err)
+	}
+
+	return s
+}
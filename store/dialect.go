@@ -0,0 +1,361 @@
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Dialect abstracts the differences in SQL syntax between databases, allowing
+// the DB store to work with each of them.
+type Dialect interface {
+	// Name returns the name of the database/sql driver the dialect is for.
+	Name() string
+
+	// Quote returns identifier quoted for use as a table or column name.
+	Quote(identifier string) string
+
+	// Placeholder returns the placeholder for the n'th query argument,
+	// counting from 1.
+	Placeholder(n int) string
+
+	// Upsert returns a statement inserting a row into table, replacing columns
+	// if a row with the same key already exists. Identifiers must already be
+	// quoted.
+	//
+	// The statement uses "?" placeholders for key followed by each of columns,
+	// which are replaced by the DB store.
+	Upsert(table, key string, columns ...string) string
+
+	// SerialColumn returns the definition of an auto-incrementing primary key
+	// column named name, used when creating tables - see DB.Migrate.
+	SerialColumn(name string) string
+
+	// BlobType returns the column type used to hold encoded secrets.
+	BlobType() string
+
+	// HasUniqueIndex reports whether column has a UNIQUE index (or constraint)
+	// of its own in table. Identifiers must not be quoted.
+	HasUniqueIndex(db *sql.DB, table, column string) (bool, error)
+
+	// TranslateError returns the store error matching the driver error err -
+	// ErrAlreadyExists for a UNIQUE constraint violation, ErrMissingTable or
+	// ErrMissingColumn - or err unchanged if there is no match.
+	TranslateError(err error) error
+}
+
+// The supported SQL dialects.
+var (
+	MySQL    Dialect = mysqlDialect{}
+	Postgres Dialect = postgresDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// DialectFor returns the Dialect for the named database/sql driver.
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "mysql":
+		return MySQL, nil
+
+	case "postgres":
+		return Postgres, nil
+
+	case "sqlite3":
+		return SQLite, nil
+
+	default:
+		return nil, ErrUnknownDialect
+	}
+}
+
+type mysqlDialect struct{}
+
+func (d mysqlDialect) Name() string { return "mysql" }
+
+func (d mysqlDialect) Quote(identifier string) string {
+	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
+}
+
+func (d mysqlDialect) Placeholder(n int) string { return "?" }
+
+func (d mysqlDialect) Upsert(table, key string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, c := range columns {
+		set[i] = fmt.Sprintf("%s = VALUES(%s)", c, c)
+	}
+
+	return insertSQL(table, key, columns) + " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+}
+
+func (d mysqlDialect) SerialColumn(name string) string {
+	return name + " int unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY"
+}
+
+func (d mysqlDialect) BlobType() string { return "blob" }
+
+func (d mysqlDialect) HasUniqueIndex(db *sql.DB, table, column string) (bool, error) {
+	// Only indexes covering the single column guarantee it is unique
+	return countRows(db, `
+		SELECT COUNT(*) FROM (
+			SELECT INDEX_NAME FROM information_schema.STATISTICS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND NON_UNIQUE = 0
+			GROUP BY INDEX_NAME
+			HAVING COUNT(*) = 1 AND MAX(COLUMN_NAME) = ?
+		) AS idx`,
+		table, column,
+	)
+}
+
+// TranslateError matches the error number of a (possibly wrapped)
+// mysql.MySQLError.
+func (d mysqlDialect) TranslateError(err error) error {
+	var e *mysql.MySQLError
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	switch e.Number {
+	case 1062: // ER_DUP_ENTRY
+		return ErrAlreadyExists
+
+	case 1146: // ER_NO_SUCH_TABLE
+		return ErrMissingTable
+
+	case 1054: // ER_BAD_FIELD_ERROR
+		return ErrMissingColumn
+
+	default:
+		return err
+	}
+}
+
+type postgresDialect struct{}
+
+func (d postgresDialect) Name() string { return "postgres" }
+
+func (d postgresDialect) Quote(identifier string) string {
+	return quoteANSI(identifier)
+}
+
+func (d postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (d postgresDialect) Upsert(table, key string, columns ...string) string {
+	return insertSQL(table, key, columns) + onConflictSQL(key, columns)
+}
+
+func (d postgresDialect) SerialColumn(name string) string {
+	return name + " serial PRIMARY KEY"
+}
+
+func (d postgresDialect) BlobType() string { return "bytea" }
+
+func (d postgresDialect) HasUniqueIndex(db *sql.DB, table, column string) (bool, error) {
+	return countRows(db, `
+		SELECT COUNT(*) FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indrelid
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = i.indkey[0]
+		WHERE c.relname = $1 AND pg_table_is_visible(c.oid)
+		AND i.indisunique AND i.indnatts = 1 AND i.indpred IS NULL
+		AND a.attname = $2`,
+		table, column,
+	)
+}
+
+// sqlStater is implemented by Postgres driver errors (both lib/pq and pgx),
+// returning the SQLSTATE error code.
+type sqlStater interface {
+	SQLState() string
+}
+
+func (d postgresDialect) TranslateError(err error) error {
+	var e sqlStater
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	switch e.SQLState() {
+	case "23505": // unique_violation
+		return ErrAlreadyExists
+
+	case "42P01": // undefined_table
+		return ErrMissingTable
+
+	case "42703": // undefined_column
+		return ErrMissingColumn
+
+	default:
+		return err
+	}
+}
+
+type sqliteDialect struct{}
+
+func (d sqliteDialect) Name() string { return "sqlite3" }
+
+func (d sqliteDialect) Quote(identifier string) string {
+	return quoteANSI(identifier)
+}
+
+func (d sqliteDialect) Placeholder(n int) string { return "?" }
+
+// Upsert requires SQLite 3.24.0 or newer.
+func (d sqliteDialect) Upsert(table, key string, columns ...string) string {
+	return insertSQL(table, key, columns) + onConflictSQL(key, columns)
+}
+
+func (d sqliteDialect) SerialColumn(name string) string {
+	return name + " INTEGER NOT NULL PRIMARY KEY"
+}
+
+func (d sqliteDialect) BlobType() string { return "BLOB" }
+
+// HasUniqueIndex requires SQLite 3.8.9 or newer.
+func (d sqliteDialect) HasUniqueIndex(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", d.Quote(table)))
+	if err != nil {
+		return false, err
+	}
+
+	// Collect the unique indexes before inspecting them, as each in-memory
+	// database is private to a single connection
+	var indexes []string
+	for rows.Next() {
+		var seq int
+		var name, origin string
+		var unique, partial bool
+
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return false, err
+		}
+
+		if unique && !partial {
+			indexes = append(indexes, name)
+		}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, index := range indexes {
+		columns, err := d.indexColumns(db, index)
+		if err != nil {
+			return false, err
+		}
+
+		if len(columns) == 1 && columns[0] == column {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// indexColumns returns the names of the columns covered by index.
+func (d sqliteDialect) indexColumns(db *sql.DB, index string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", d.Quote(index)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var seq, cid int
+		var name sql.NullString
+
+		if err := rows.Scan(&seq, &cid, &name); err != nil {
+			return nil, err
+		}
+
+		// Expressions have no column name, and never match
+		columns = append(columns, name.String)
+	}
+
+	return columns, rows.Err()
+}
+
+// TranslateError matches the error message, as the error codes are only
+// available from the (cgo) driver package.
+func (d sqliteDialect) TranslateError(err error) error {
+	msg := err.Error()
+
+	switch {
+	case strings.HasPrefix(msg, "UNIQUE constraint failed"):
+		return ErrAlreadyExists
+
+	case strings.HasPrefix(msg, "no such table"):
+		return ErrMissingTable
+
+	case strings.HasPrefix(msg, "no such column"), strings.Contains(msg, "has no column named"):
+		return ErrMissingColumn
+
+	default:
+		return err
+	}
+}
+
+// quoteANSI quotes identifier with double quotes, as per the SQL standard.
+func quoteANSI(identifier string) string {
+	return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+}
+
+// countRows runs the COUNT(*) query, returning true if the count is non-zero.
+func countRows(db *sql.DB, query string, args ...interface{}) (bool, error) {
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// insertSQL returns an INSERT statement for key and columns.
+func insertSQL(table, key string, columns []string) string {
+	cols := append([]string{key}, columns...)
+	placeholders := strings.Repeat("?, ", len(cols))
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(cols, ", "), placeholders[:len(placeholders)-2],
+	)
+}
+
+// onConflictSQL returns the ON CONFLICT clause shared by Postgres and SQLite.
+func onConflictSQL(key string, columns []string) string {
+	set := make([]string, len(columns))
+	for i, c := range columns {
+		set[i] = fmt.Sprintf("%s = excluded.%s", c, c)
+	}
+
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", key, strings.Join(set, ", "))
+}
+
+// rebind replaces each "?" placeholder in query with the placeholder used by
+// d. Identifiers in query must not contain "?".
+func rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+
+	var buf bytes.Buffer
+	n := 0
+
+	for _, r := range query {
+		if r != '?' {
+			buf.WriteRune(r)
+			continue
+		}
+
+		n++
+		buf.WriteString(d.Placeholder(n))
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestDialects(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		dialect Dialect
+		// Expected results.
+		wantQuote  string
+		wantUpsert string
+		wantRebind string
+	}{
+		{
+			"MySQL",
+			MySQL,
+			"`se``crets`",
+			"INSERT INTO t (k, v, e) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE v = VALUES(v), e = VALUES(e)",
+			"SELECT v FROM t WHERE k = ? AND e > ?",
+		},
+		{
+			"Postgres",
+			Postgres,
+			`"se` + "`" + `crets"`,
+			"INSERT INTO t (k, v, e) VALUES (?, ?, ?) ON CONFLICT (k) DO UPDATE SET v = excluded.v, e = excluded.e",
+			"SELECT v FROM t WHERE k = $1 AND e > $2",
+		},
+		{
+			"SQLite",
+			SQLite,
+			`"se` + "`" + `crets"`,
+			"INSERT INTO t (k, v, e) VALUES (?, ?, ?) ON CONFLICT (k) DO UPDATE SET v = excluded.v, e = excluded.e",
+			"SELECT v FROM t WHERE k = ? AND e > ?",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.Quote("se`crets"); got != tt.wantQuote {
+			t.Errorf("%q. Dialect.Quote() = %v, want %v", tt.name, got, tt.wantQuote)
+		}
+
+		if got := tt.dialect.Upsert("t", "k", "v", "e"); got != tt.wantUpsert {
+			t.Errorf("%q. Dialect.Upsert() = %v, want %v", tt.name, got, tt.wantUpsert)
+		}
+
+		if got := rebind(tt.dialect, "SELECT v FROM t WHERE k = ? AND e > ?"); got != tt.wantRebind {
+			t.Errorf("%q. rebind() = %v, want %v", tt.name, got, tt.wantRebind)
+		}
+
+		if got, err := DialectFor(tt.dialect.Name()); err != nil || got != tt.dialect {
+			t.Errorf("%q. DialectFor() = %v, %v, want %v", tt.name, got, err, tt.dialect)
+		}
+	}
+
+	if _, err := DialectFor("oracle"); err != ErrUnknownDialect {
+		t.Errorf("DialectFor() error = %v, want %v", err, ErrUnknownDialect)
+	}
+}
+
+func TestPostgresQuoting(t *testing.T) {
+	if got, want := Postgres.Quote(`a"b`), `"a""b"`; got != want {
+		t.Errorf("Postgres.Quote() = %v, want %v", got, want)
+	}
+}
+
+// pqError mimics the Postgres driver errors, which expose the SQLSTATE code.
+type pqError string
+
+func (e pqError) Error() string    { return "pq: " + string(e) }
+func (e pqError) SQLState() string { return string(e) }
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("Error: connection refused")
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		dialect Dialect
+		err     error
+		// Expected results.
+		want error
+	}{
+		{"MySQL duplicate", MySQL, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a' for key 'idx_name'"}, ErrAlreadyExists},
+		{"MySQL duplicate wrapped", MySQL, fmt.Errorf("insert: %w", &mysql.MySQLError{Number: 1062}), ErrAlreadyExists},
+		{"MySQL missing table", MySQL, &mysql.MySQLError{Number: 1146, Message: "Table 'cryptic.secrets' doesn't exist"}, ErrMissingTable},
+		{"MySQL missing column", MySQL, &mysql.MySQLError{Number: 1054, Message: "Unknown column 'expires_at' in 'field list'"}, ErrMissingColumn},
+		{"MySQL other error number", MySQL, &mysql.MySQLError{Number: 1045, Message: "Access denied"}, nil},
+		{"MySQL error message", MySQL, errors.New("Error 1062: Duplicate entry 'a' for key 'idx_name'"), nil},
+		{"MySQL other error", MySQL, other, other},
+		{"Postgres duplicate", Postgres, pqError("23505"), ErrAlreadyExists},
+		{"Postgres duplicate wrapped", Postgres, fmt.Errorf("insert: %w", pqError("23505")), ErrAlreadyExists},
+		{"Postgres missing table", Postgres, pqError("42P01"), ErrMissingTable},
+		{"Postgres missing column", Postgres, pqError("42703"), ErrMissingColumn},
+		{"Postgres other code", Postgres, pqError("28P01"), nil},
+		{"Postgres other error", Postgres, other, other},
+		{"SQLite duplicate", SQLite, errors.New("UNIQUE constraint failed: secrets.name"), ErrAlreadyExists},
+		{"SQLite missing table", SQLite, errors.New("no such table: secrets"), ErrMissingTable},
+		{"SQLite missing column", SQLite, errors.New("no such column: expires_at"), ErrMissingColumn},
+		{"SQLite missing insert column", SQLite, errors.New("table secrets has no column named expires_at"), ErrMissingColumn},
+		{"SQLite other error", SQLite, other, other},
+	}
+
+	for _, tt := range tests {
+		// A nil want means the error is returned unchanged
+		want := tt.want
+		if want == nil {
+			want = tt.err
+		}
+
+		if got := tt.dialect.TranslateError(tt.err); got != want {
+			t.Errorf("%q. Dialect.TranslateError() = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+// TestDbTranslatesErrors ensures the DB store returns store errors in place of
+// the sqlite driver errors.
+func TestDbTranslatesErrors(t *testing.T) {
+	if _, err := NewDB(newSQLiteDB(t), &DBOpts{Dialect: SQLite}); err != ErrMissingTable {
+		t.Errorf("NewDB() without table error = %v, want %v", err, ErrMissingTable)
+	}
+
+	s := newTestDB(t, DBOpts{})
+	data := &encryptor.EncryptedData{Ciphertext: []byte("secret")}
+
+	if err := s.Put("secret", data); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if err := s.Put("secret", data); err != ErrAlreadyExists {
+		t.Errorf("Put() duplicate error = %v, want %v", err, ErrAlreadyExists)
+	}
+
+	s = newTestDB(t, DBOpts{ExpiresColumn: "expires_at"}, expiresColumnSQL)
+	if err := s.PutWithTTL("secret", data, time.Hour); err != nil {
+		t.Fatalf("PutWithTTL() error = %v", err)
+	}
+
+	if err := s.PutWithTTL("secret", data, time.Hour); err != ErrAlreadyExists {
+		t.Errorf("PutWithTTL() duplicate error = %v, want %v", err, ErrAlreadyExists)
+	}
+}
@@ -0,0 +1,344 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// credstashVersion is the version of each secret stored using the credstash
+// table layout - credstash zero-pads versions to 19 digits so they sort
+// lexically.
+var credstashVersion = fmt.Sprintf("%019d", 1)
+
+// DynamoDB stores each secret as an item in an Amazon DynamoDB table, keyed by
+// the secret name (a string hash key called "name").
+//
+// Put uses a conditional write (attribute_not_exists) so an existing secret is
+// never overwritten, even by a concurrent writer.
+//
+// When using the credstash layout the table also has a string range key called
+// "version", as created by "credstash setup", and the secret is stored in the
+// "contents" attribute. Cryptic and credstash secrets can share a table and
+// are listed by both, but secrets aren't readable by the other tool as the
+// encryption formats differ.
+type DynamoDB struct {
+	svc       dynamoInterface
+	table     string
+	credstash bool
+}
+
+type dynamoInterface interface {
+	PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error)
+	GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error)
+	DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error)
+	QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error)
+	ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error)
+}
+
+// DynamoDBOpts configures the DynamoDB store.
+type DynamoDBOpts struct {
+	Table  string
+	Region string
+
+	// Endpoint is the URL of a DynamoDB compatible service, such as DynamoDB
+	// Local.
+	Endpoint string
+
+	// Credstash selects credstash's table layout, with a "version" range key.
+	Credstash bool
+}
+
+// NewDynamoDB returns an initialised DynamoDB store.
+func NewDynamoDB(opts *DynamoDBOpts) (*DynamoDB, error) {
+	if opts.Table == "" {
+		return nil, ErrNoTable
+	}
+
+	cfg := &aws.Config{Region: aws.String(opts.Region)}
+	if opts.Endpoint != "" {
+		cfg.Endpoint = aws.String(opts.Endpoint)
+	}
+
+	return &DynamoDB{
+		svc:       dynamodb.New(session.New(), cfg),
+		table:     opts.Table,
+		credstash: opts.Credstash,
+	}, nil
+}
+
+// Put stores data under the given name.
+func (s *DynamoDB) Put(name string, data *encryptor.EncryptedData) error {
+	return s.PutContext(context.Background(), name, data)
+}
+
+// PutContext is the same as Put, but the request is cancelled if ctx is
+// cancelled before it completes.
+func (s *DynamoDB) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	item := s.key(name)
+	if s.credstash {
+		// credstash stores every attribute as a string
+		item["contents"] = &dynamodb.AttributeValue{S: aws.String(base64.StdEncoding.EncodeToString(buf))}
+	} else {
+		item["data"] = &dynamodb.AttributeValue{B: buf}
+	}
+
+	// Only create the item if the key is unused
+	_, err = s.svc.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(s.table),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#n)"),
+		ExpressionAttributeNames: map[string]*string{"#n": aws.String("name")},
+	})
+
+	if dynamoConditionFailed(err) {
+		return ErrAlreadyExists
+	}
+
+	return err
+}
+
+// Get fetches the secret stored under name.
+func (s *DynamoDB) Get(name string) (*encryptor.EncryptedData, error) {
+	return s.GetContext(context.Background(), name)
+}
+
+// GetContext is the same as Get, but the request is cancelled if ctx is
+// cancelled before it completes.
+//
+// When using the credstash layout, the latest version of the secret is
+// returned, or ErrCredstashSecret if it was written by credstash.
+func (s *DynamoDB) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	if s.credstash {
+		return s.getLatest(ctx, name)
+	}
+
+	resp, err := s.svc.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            s.key(name),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attr, ok := resp.Item["data"]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	d := &encryptor.EncryptedData{}
+	if err := d.UnmarshalBinary(attr.B); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// getLatest fetches the highest version of name from a credstash layout table.
+func (s *DynamoDB) getLatest(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	resp, err := s.svc.QueryWithContext(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(s.table),
+		KeyConditionExpression:   aws.String("#n = :name"),
+		ExpressionAttributeNames: map[string]*string{"#n": aws.String("name")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":name": {S: aws.String(name)},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int64(1),
+		ConsistentRead:   aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Items) == 0 {
+		return nil, ErrNotFound
+	}
+
+	attr, ok := resp.Items[0]["contents"]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	// credstash stores the wrapped data key alongside the contents, cryptic
+	// stores everything in the contents
+	if _, ok := resp.Items[0]["key"]; ok {
+		return nil, ErrCredstashSecret
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(aws.StringValue(attr.S))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &encryptor.EncryptedData{}
+	if err := d.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// List returns the names of the secrets in the table, in lexical order.
+//
+// DynamoDB can't return hash keys in order, so the whole table is scanned for
+// each page - List is slow (and consumes read capacity) for very large tables.
+func (s *DynamoDB) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	// Versions of a credstash secret share a name, so names are deduplicated
+	seen := map[string]bool{}
+	names := []string{}
+
+	input := &dynamodb.ScanInput{
+		TableName:                aws.String(s.table),
+		ProjectionExpression:     aws.String("#n"),
+		ExpressionAttributeNames: map[string]*string{"#n": aws.String("name")},
+	}
+
+	for {
+		resp, err := s.svc.ScanWithContext(context.Background(), input)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, item := range resp.Items {
+			name := aws.StringValue(item["name"].S)
+			if seen[name] || !strings.HasPrefix(name, opts.Prefix) || name <= opts.Cursor {
+				continue
+			}
+
+			seen[name] = true
+			names = append(names, name)
+		}
+
+		if len(resp.LastEvaluatedKey) == 0 {
+			break
+		}
+
+		input.ExclusiveStartKey = resp.LastEvaluatedKey
+	}
+
+	sort.Strings(names)
+
+	limit := listLimit(opts)
+	if len(names) <= limit {
+		return names, "", nil
+	}
+
+	names = names[:limit]
+	return names, names[limit-1], nil
+}
+
+// Delete removes the secret stored under name.
+func (s *DynamoDB) Delete(name string) error {
+	return s.DeleteContext(context.Background(), name)
+}
+
+// DeleteContext is the same as Delete, but the requests are cancelled if ctx is
+// cancelled before they complete.
+//
+// When using the credstash layout every version of the secret is removed.
+func (s *DynamoDB) DeleteContext(ctx context.Context, name string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	if !s.credstash {
+		return s.deleteItem(ctx, s.key(name))
+	}
+
+	resp, err := s.svc.QueryWithContext(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(s.table),
+		KeyConditionExpression:   aws.String("#n = :name"),
+		ProjectionExpression:     aws.String("#n, version"),
+		ExpressionAttributeNames: map[string]*string{"#n": aws.String("name")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":name": {S: aws.String(name)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Items) == 0 {
+		return ErrNotFound
+	}
+
+	for _, item := range resp.Items {
+		err := s.deleteItem(ctx, map[string]*dynamodb.AttributeValue{
+			"name":    item["name"],
+			"version": item["version"],
+		})
+
+		// A concurrent Delete may have removed some versions already
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteItem removes the item with the given key, returning ErrNotFound if it
+// does not exist.
+func (s *DynamoDB) deleteItem(ctx context.Context, key map[string]*dynamodb.AttributeValue) error {
+	_, err := s.svc.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      key,
+		ConditionExpression:      aws.String("attribute_exists(#n)"),
+		ExpressionAttributeNames: map[string]*string{"#n": aws.String("name")},
+	})
+
+	if dynamoConditionFailed(err) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// key returns the primary key of the item holding name.
+func (s *DynamoDB) key(name string) map[string]*dynamodb.AttributeValue {
+	key := map[string]*dynamodb.AttributeValue{
+		"name": {S: aws.String(name)},
+	}
+
+	if s.credstash {
+		key["version"] = &dynamodb.AttributeValue{S: aws.String(credstashVersion)}
+	}
+
+	return key
+}
+
+// dynamoConditionFailed returns true if err reports a failed condition
+// expression.
+func dynamoConditionFailed(err error) bool {
+	e, ok := err.(awserr.Error)
+	return ok && e.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// TestDynamoDBIntegration runs the DynamoDB store against a live DynamoDB
+// compatible service (such as DynamoDB Local) using either table layout, to
+// catch differences between the mock and the real condition expressions.
+func TestDynamoDBIntegration(t *testing.T) {
+	// If we don't have an endpoint to connect to, skip the DynamoDB
+	// integration tests
+	endpoint := os.Getenv("DYNAMODB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("no DYNAMODB_ENDPOINT environment variable set, skipping integration tests")
+	}
+
+	// DynamoDB Local accepts any credentials, but the SDK needs some
+	if os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		os.Setenv("AWS_ACCESS_KEY_ID", "cryptic")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "cryptic")
+	}
+
+	svc := dynamodb.New(session.New(), &aws.Config{
+		Region:   aws.String("eu-west-1"),
+		Endpoint: aws.String(endpoint),
+	})
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		credstash bool
+	}{
+		{"Default layout", false},
+		{"Credstash layout", true},
+	}
+
+	data := &encryptor.EncryptedData{
+		Ciphertext: []byte("ciphertext"),
+		HMAC:       []byte("hmac"),
+		Type:       encryptor.Nop,
+		Context:    map[string]interface{}{},
+	}
+
+	for _, tt := range tests {
+		table := fmt.Sprintf("cryptic-integration-%d", time.Now().UnixNano())
+		if err := createDynamoTable(svc, table, tt.credstash); err != nil {
+			t.Fatalf("%q. failed to create table: %s", tt.name, err)
+		}
+		defer svc.DeleteTable(&dynamodb.DeleteTableInput{TableName: aws.String(table)})
+
+		s, err := NewDynamoDB(&DynamoDBOpts{
+			Table:     table,
+			Region:    "eu-west-1",
+			Endpoint:  endpoint,
+			Credstash: tt.credstash,
+		})
+		if err != nil {
+			t.Fatalf("%q. NewDynamoDB() error = %v", tt.name, err)
+		}
+
+		if _, err := s.Get("secret"); err != ErrNotFound {
+			t.Errorf("%q. DynamoDB.Get() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if err := s.Put("secret", data); err != nil {
+			t.Fatalf("%q. DynamoDB.Put() error = %v", tt.name, err)
+		}
+
+		if err := s.Put("secret", data); err != ErrAlreadyExists {
+			t.Errorf("%q. DynamoDB.Put() existing error = %v, want %v", tt.name, err, ErrAlreadyExists)
+		}
+
+		if got, err := s.Get("secret"); err != nil || !reflect.DeepEqual(got, data) {
+			t.Errorf("%q. DynamoDB.Get() = %v, %v, want %v", tt.name, got, err, data)
+		}
+
+		if err := s.Put("other", data); err != nil {
+			t.Errorf("%q. DynamoDB.Put() error = %v", tt.name, err)
+		}
+
+		if names, _, err := s.List(nil); err != nil || !reflect.DeepEqual(names, []string{"other", "secret"}) {
+			t.Errorf("%q. DynamoDB.List() = %v, %v, want [other secret]", tt.name, names, err)
+		}
+
+		if err := s.Delete("secret"); err != nil {
+			t.Errorf("%q. DynamoDB.Delete() error = %v", tt.name, err)
+		}
+
+		if err := s.Delete("secret"); err != ErrNotFound {
+			t.Errorf("%q. DynamoDB.Delete() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		// The name can be used again once deleted
+		if err := s.Put("secret", data); err != nil {
+			t.Errorf("%q. DynamoDB.Put() after Delete() error = %v", tt.name, err)
+		}
+	}
+}
+
+// createDynamoTable creates table with a "name" hash key, and the "version"
+// range key of the credstash layout if credstash is true, waiting for it to
+// become active.
+func createDynamoTable(svc *dynamodb.DynamoDB, table string, credstash bool) error {
+	input := &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("name"), AttributeType: aws.String("S")},
+		},
+		KeySchema: []*dynamodb.KeySchemaElement{
+			{AttributeName: aws.String("name"), KeyType: aws.String("HASH")},
+		},
+		BillingMode: aws.String("PAY_PER_REQUEST"),
+	}
+
+	if credstash {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String("version"), AttributeType: aws.String("S"),
+		})
+		input.KeySchema = append(input.KeySchema, &dynamodb.KeySchemaElement{
+			AttributeName: aws.String("version"), KeyType: aws.String("RANGE"),
+		})
+	}
+
+	if _, err := svc.CreateTable(input); err != nil {
+		return err
+	}
+
+	return svc.WaitUntilTableExists(&dynamodb.DescribeTableInput{TableName: aws.String(table)})
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"encoding/base64"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// mockDynamoDB is an in-memory DynamoDB table with a "name" hash key and an
+// optional "version" range key, honouring the condition expressions used by
+// the store. Scans return a page of two items at a time.
+type mockDynamoDB struct {
+	mu    sync.Mutex
+	items map[[2]string]map[string]*dynamodb.AttributeValue
+}
+
+func newMockDynamoDB() *mockDynamoDB {
+	return &mockDynamoDB{items: map[[2]string]map[string]*dynamodb.AttributeValue{}}
+}
+
+func mockDynamoKey(item map[string]*dynamodb.AttributeValue) [2]string {
+	k := [2]string{aws.StringValue(item["name"].S)}
+	if v, ok := item["version"]; ok {
+		k[1] = aws.StringValue(v.S)
+	}
+
+	return k
+}
+
+func (m *mockDynamoDB) sortedKeys() [][2]string {
+	keys := [][2]string{}
+	for k := range m.items {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+
+		return keys[i][1] < keys[j][1]
+	})
+
+	return keys
+}
+
+func (m *mockDynamoDB) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := mockDynamoKey(input.Item)
+	if _, ok := m.items[key]; ok && aws.StringValue(input.ConditionExpression) == "attribute_not_exists(#n)" {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+
+	m.items[key] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *mockDynamoDB) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return &dynamodb.GetItemOutput{Item: m.items[mockDynamoKey(input.Key)]}, nil
+}
+
+func (m *mockDynamoDB) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := mockDynamoKey(input.Key)
+	if _, ok := m.items[key]; !ok && aws.StringValue(input.ConditionExpression) == "attribute_exists(#n)" {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+	}
+
+	delete(m.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *mockDynamoDB) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := aws.StringValue(input.ExpressionAttributeValues[":name"].S)
+
+	out := &dynamodb.QueryOutput{}
+	for _, k := range m.sortedKeys() {
+		if k[0] == name {
+			out.Items = append(out.Items, m.items[k])
+		}
+	}
+
+	if !aws.BoolValue(input.ScanIndexForward) && input.ScanIndexForward != nil {
+		for i, j := 0, len(out.Items)-1; i < j; i, j = i+1, j-1 {
+			out.Items[i], out.Items[j] = out.Items[j], out.Items[i]
+		}
+	}
+
+	if max := int(aws.Int64Value(input.Limit)); max > 0 && len(out.Items) > max {
+		out.Items = out.Items[:max]
+	}
+
+	return out, nil
+}
+
+func (m *mockDynamoDB) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var start [2]string
+	if input.ExclusiveStartKey != nil {
+		start = mockDynamoKey(input.ExclusiveStartKey)
+	}
+
+	out := &dynamodb.ScanOutput{}
+	for _, k := range m.sortedKeys() {
+		if input.ExclusiveStartKey != nil && (k[0] < start[0] || k[0] == start[0] && k[1] <= start[1]) {
+			continue
+		}
+
+		if len(out.Items) == 2 {
+			out.LastEvaluatedKey = out.Items[1]
+			break
+		}
+
+		out.Items = append(out.Items, m.items[k])
+	}
+
+	return out, nil
+}
+
+// TestDynamoDB ensures the DynamoDB store behaves the same as Memory using
+// either table layout.
+func TestDynamoDB(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		credstash bool
+		// Expected results.
+		wantKey [2]string
+	}{
+		{"Default layout", false, [2]string{"secret", ""}},
+		{"Credstash layout", true, [2]string{"secret", "0000000000000000001"}},
+	}
+
+	data := &encryptor.EncryptedData{
+		Ciphertext: []byte("ciphertext"),
+		HMAC:       []byte("hmac"),
+		Type:       encryptor.Nop,
+		Context:    map[string]interface{}{},
+	}
+
+	for _, tt := range tests {
+		mock := newMockDynamoDB()
+		s := &DynamoDB{svc: mock, table: "table", credstash: tt.credstash}
+
+		if err := s.Put("", data); err != ErrInvalidName {
+			t.Errorf("%q. DynamoDB.Put() empty name error = %v, want %v", tt.name, err, ErrInvalidName)
+		}
+
+		if _, err := s.Get("secret"); err != ErrNotFound {
+			t.Errorf("%q. DynamoDB.Get() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if err := s.Put("secret", data); err != nil {
+			t.Fatalf("%q. DynamoDB.Put() error = %v", tt.name, err)
+		}
+
+		if err := s.Put("secret", data); err != ErrAlreadyExists {
+			t.Errorf("%q. DynamoDB.Put() existing error = %v, want %v", tt.name, err, ErrAlreadyExists)
+		}
+
+		if got, err := s.Get("secret"); err != nil || !reflect.DeepEqual(got, data) {
+			t.Errorf("%q. DynamoDB.Get() = %v, %v, want %v", tt.name, got, err, data)
+		}
+
+		if _, ok := mock.items[tt.wantKey]; !ok {
+			t.Errorf("%q. DynamoDB.Put() items = %v, want key %v", tt.name, mock.items, tt.wantKey)
+		}
+
+		if err := s.Delete("secret"); err != nil {
+			t.Errorf("%q. DynamoDB.Delete() error = %v", tt.name, err)
+		}
+
+		if err := s.Delete("secret"); err != ErrNotFound {
+			t.Errorf("%q. DynamoDB.Delete() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+	}
+
+	if _, err := NewDynamoDB(&DynamoDBOpts{}); err != ErrNoTable {
+		t.Errorf("NewDynamoDB() without table error = %v, want %v", err, ErrNoTable)
+	}
+}
+
+// TestDynamoDBCredstashVersions ensures the latest version of a secret in the
+// credstash layout is read, every version is deleted, and secrets written by
+// credstash itself are reported.
+func TestDynamoDBCredstashVersions(t *testing.T) {
+	mock := newMockDynamoDB()
+	s := &DynamoDB{svc: mock, table: "credential-store", credstash: true}
+
+	old := &encryptor.EncryptedData{Ciphertext: []byte("old"), Context: map[string]interface{}{}}
+	if err := s.Put("secret", old); err != nil {
+		t.Fatalf("DynamoDB.Put() error = %v", err)
+	}
+
+	// Store a second version as credstash would
+	latest := &encryptor.EncryptedData{Ciphertext: []byte("latest"), Context: map[string]interface{}{}}
+	buf, err := latest.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := map[string]*dynamodb.AttributeValue{
+		"name":     {S: aws.String("secret")},
+		"version":  {S: aws.String("0000000000000000002")},
+		"contents": {S: aws.String(base64.StdEncoding.EncodeToString(buf))},
+	}
+	mock.items[mockDynamoKey(item)] = item
+
+	got, err := s.Get("secret")
+	if err != nil || string(got.Ciphertext) != "latest" {
+		t.Errorf("DynamoDB.Get() = %v, %v, want latest version", got, err)
+	}
+
+	if names, _, err := s.List(nil); err != nil || !reflect.DeepEqual(names, []string{"secret"}) {
+		t.Errorf("DynamoDB.List() = %v, %v, want [secret]", names, err)
+	}
+
+	if err := s.Delete("secret"); err != nil {
+		t.Errorf("DynamoDB.Delete() error = %v", err)
+	}
+
+	if len(mock.items) != 0 {
+		t.Errorf("DynamoDB.Delete() left items %v", mock.items)
+	}
+
+	// A secret written by credstash itself
+	item = map[string]*dynamodb.AttributeValue{
+		"name":     {S: aws.String("credstash")},
+		"version":  {S: aws.String("0000000000000000001")},
+		"key":      {S: aws.String("d3JhcHBlZCBrZXk=")},
+		"hmac":     {S: aws.String("686d6163")},
+		"digest":   {S: aws.String("SHA256")},
+		"contents": {S: aws.String("Y2lwaGVydGV4dA==")},
+	}
+	mock.items[mockDynamoKey(item)] = item
+
+	if _, err := s.Get("credstash"); err != ErrCredstashSecret {
+		t.Errorf("DynamoDB.Get() credstash secret error = %v, want %v", err, ErrCredstashSecret)
+	}
+}
+
+// newTestDynamoDB returns a DynamoDB store backed by an in-memory mock.
+func newTestDynamoDB() *DynamoDB {
+	return &DynamoDB{svc: newMockDynamoDB(), table: "table"}
+}
@@ -12,4 +12,110 @@ var (
 	// ErrAlreadyExists is returned when attempting to Put() a secret with the
 	// same name as an existing entry.
 	ErrAlreadyExists = errors.New("store: secret already exists")
+
+	// ErrBlindKeyTooShort is returned when a name blinding key is too short to
+	// be useful.
+	ErrBlindKeyTooShort = errors.New("store: blinding key is required")
+
+	// ErrIrreversibleName is returned when attempting to recover the original
+	// name from a name blinded with an irreversible Blinder.
+	ErrIrreversibleName = errors.New("store: blinded name cannot be reversed")
+
+	// ErrListUnsupported is returned when attempting to list the secrets in a
+	// store that does not implement Lister.
+	ErrListUnsupported = errors.New("store: listing secrets is not supported")
+
+	// ErrInvalidNamespace is returned when a namespace is empty or contains the
+	// namespace separator.
+	ErrInvalidNamespace = errors.New("store: invalid namespace")
+
+	// ErrInvalidVersion is returned when a version number is less than 1, or
+	// when asked to prune all versions of a secret.
+	ErrInvalidVersion = errors.New("store: invalid version")
+
+	// ErrVersioningUnsupported is returned when attempting to use versioned
+	// secrets with a store that does not implement Versioner, or does not have
+	// versioning enabled.
+	ErrVersioningUnsupported = errors.New("store: versioning is not supported")
+
+	// ErrConflict is returned by CompareAndSwap when the secret has been
+	// changed since it was read.
+	ErrConflict = errors.New("store: secret has been modified")
+
+	// ErrUpdateUnsupported is returned when attempting to update a secret in a
+	// store that does not implement Updater.
+	ErrUpdateUnsupported = errors.New("store: updating secrets is not supported")
+
+	// ErrNoTransactions is returned when a Redis client does not support the
+	// transactions needed to update secrets atomically, such as a cluster
+	// client.
+	ErrNoTransactions = errors.New("store: redis client does not support transactions")
+
+	// ErrInvalidTTL is returned when a secret is stored with an expiry of zero
+	// or less.
+	ErrInvalidTTL = errors.New("store: invalid ttl")
+
+	// ErrExpiryUnsupported is returned when attempting to store an expiring
+	// secret in a store that does not implement Expirer, or does not have
+	// expiry enabled.
+	ErrExpiryUnsupported = errors.New("store: expiring secrets is not supported")
+
+	// ErrBulkUnsupported is returned by the GetMany method of a store wrapper
+	// when the store it wraps does not implement BulkGetter - fetch each
+	// secret with Get instead.
+	ErrBulkUnsupported = errors.New("store: bulk fetching secrets is not supported")
+
+	// ErrWatchUnsupported is returned when attempting to watch a store that
+	// does not implement Watcher.
+	ErrWatchUnsupported = errors.New("store: watching secrets is not supported")
+
+	// ErrMetadataUnsupported is returned when attempting to store metadata in a
+	// store that does not implement Describer, or does not have metadata
+	// enabled.
+	ErrMetadataUnsupported = errors.New("store: metadata is not supported")
+
+	// ErrNoMetadata is returned when fetching the metadata of a secret stored
+	// without any.
+	ErrNoMetadata = errors.New("store: secret has no metadata")
+
+	// ErrInvalidMetadata is returned when the metadata MAC is incorrect - the
+	// metadata has been changed, or describes a different secret.
+	ErrInvalidMetadata = errors.New("store: invalid metadata mac")
+
+	// ErrMetadataKeyTooShort is returned when signing or verifying metadata
+	// with an empty key.
+	ErrMetadataKeyTooShort = errors.New("store: metadata key too short")
+
+	// ErrUnknownDialect is returned when there is no SQL Dialect for a
+	// database driver.
+	ErrUnknownDialect = errors.New("store: unknown sql dialect")
+
+	// ErrMissingTable is returned by the DB store when a table it uses does
+	// not exist - see DB.Migrate.
+	ErrMissingTable = errors.New("store: table does not exist")
+
+	// ErrMissingColumn is returned by the DB store when a column it uses does
+	// not exist, such as the expiry column after enabling expiring secrets -
+	// see DB.Migrate.
+	ErrMissingColumn = errors.New("store: column does not exist")
+
+	// ErrNoUniqueKey is returned by DB.Migrate when the key column of the
+	// secrets table does not have a UNIQUE index, which Put relies on to never
+	// overwrite a secret.
+	ErrNoUniqueKey = errors.New("store: key column has no unique index")
+
+	// ErrNoBucket is returned when creating an S3 store without a bucket.
+	ErrNoBucket = errors.New("store: no bucket configured")
+
+	// ErrNoTable is returned when creating a DynamoDB store without a table.
+	ErrNoTable = errors.New("store: no table configured")
+
+	// ErrCredstashSecret is returned when reading a secret written by credstash
+	// from a DynamoDB store using the credstash layout, which cryptic cannot
+	// decrypt.
+	ErrCredstashSecret = errors.New("store: secret was written by credstash")
+
+	// ErrInvalidCursor is returned when the cursor passed to a Lister was not
+	// returned by the same store.
+	ErrInvalidCursor = errors.New("store: invalid list cursor")
 )
@@ -0,0 +1,419 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Etcd stores each secret as a key in an etcd v3 cluster, with the key Prefix
+// followed by the secret name.
+//
+// Put uses a transaction checking the key has never been created, so an
+// existing secret is never overwritten, even by a concurrent writer. Expiring
+// secrets are attached to an etcd lease, and are removed by etcd itself.
+type Etcd struct {
+	client *clientv3.Client
+	prefix string
+}
+
+// NewEtcd returns an initalised Etcd store, storing secrets under prefix (i.e.
+// "cryptic/") using client.
+func NewEtcd(client *clientv3.Client, prefix string) *Etcd {
+	return &Etcd{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Put stores data under the given name.
+func (s *Etcd) Put(name string, data *encryptor.EncryptedData) error {
+	return s.PutContext(context.Background(), name, data)
+}
+
+// PutContext is the same as Put, but the request is cancelled if ctx is
+// cancelled before it completes.
+func (s *Etcd) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return s.create(ctx, name, clientv3.OpPut(s.key(name), string(buf)))
+}
+
+// PutWithTTL stores data under the given name, attaching it to a lease that
+// expires after ttl. etcd leases have a granularity of one second, so ttl is
+// rounded up to the next whole second.
+func (s *Etcd) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	if err := checkTTL(ttl); err != nil {
+		return err
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	lease, err := s.client.Grant(ctx, int64((ttl+time.Second-1)/time.Second))
+	if err != nil {
+		return err
+	}
+
+	err = s.create(ctx, name, clientv3.OpPut(s.key(name), string(buf), clientv3.WithLease(lease.ID)))
+	if err != nil {
+		// Nothing is attached to the lease, so there's no need to wait for it
+		// to expire
+		s.client.Revoke(ctx, lease.ID)
+	}
+
+	return err
+}
+
+// create applies put if the key for name has never been created (or has since
+// been deleted), returning ErrAlreadyExists otherwise.
+func (s *Etcd) create(ctx context.Context, name string, put clientv3.Op) error {
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(s.key(name)), "=", 0)).
+		Then(put).
+		Commit()
+
+	if err != nil {
+		return err
+	}
+
+	if !resp.Succeeded {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+// Get fetches the secret stored under name.
+func (s *Etcd) Get(name string) (*encryptor.EncryptedData, error) {
+	return s.GetContext(context.Background(), name)
+}
+
+// GetContext is the same as Get, but the request is cancelled if ctx is
+// cancelled before it completes.
+func (s *Etcd) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	cur, _, err := s.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if cur == nil {
+		return nil, ErrNotFound
+	}
+
+	return cur, nil
+}
+
+// get fetches the secret stored under name and the revision it was last
+// modified in, or nil if it does not exist.
+func (s *Etcd) get(ctx context.Context, name string) (*encryptor.EncryptedData, int64, error) {
+	if name == "" {
+		return nil, 0, ErrInvalidName
+	}
+
+	resp, err := s.client.Get(ctx, s.key(name))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, 0, nil
+	}
+
+	d := &encryptor.EncryptedData{}
+	if err := d.UnmarshalBinary(resp.Kvs[0].Value); err != nil {
+		return nil, 0, err
+	}
+
+	return d, resp.Kvs[0].ModRevision, nil
+}
+
+// etcdMaxTxnOps is the default limit on the number of operations in a single
+// etcd transaction (--max-txn-ops).
+const etcdMaxTxnOps = 128
+
+// GetMany fetches the named secrets in transactions of at most 128 reads. Every
+// transaction reads the revision seen by the first, so all the secrets are read
+// at the same revision.
+func (s *Etcd) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	for _, name := range names {
+		if name == "" {
+			return nil, ErrInvalidName
+		}
+	}
+
+	out := map[string]*encryptor.EncryptedData{}
+
+	// Zero reads the latest revision
+	var rev int64
+
+	for start := 0; start < len(names); start += etcdMaxTxnOps {
+		end := start + etcdMaxTxnOps
+		if end > len(names) {
+			end = len(names)
+		}
+
+		ops := make([]clientv3.Op, 0, end-start)
+		for _, name := range names[start:end] {
+			ops = append(ops, clientv3.OpGet(s.key(name), clientv3.WithRev(rev)))
+		}
+
+		resp, err := s.client.Txn(context.Background()).Then(ops...).Commit()
+		if err != nil {
+			return nil, err
+		}
+
+		if rev == 0 {
+			rev = resp.Header.Revision
+		}
+
+		for i, r := range resp.Responses {
+			kvs := r.GetResponseRange().Kvs
+			if len(kvs) == 0 {
+				continue
+			}
+
+			d := &encryptor.EncryptedData{}
+			if err := d.UnmarshalBinary(kvs[0].Value); err != nil {
+				return nil, err
+			}
+
+			out[names[start+i]] = d
+		}
+	}
+
+	return out, nil
+}
+
+// List returns the names of the secrets under the configured prefix in lexical
+// order, using a range query so only the names in the page are read.
+func (s *Etcd) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	limit := listLimit(opts)
+
+	// Start from the cursor if it's within the prefix, skipping the cursor
+	// itself
+	start := s.key(opts.Prefix)
+	if opts.Cursor > opts.Prefix {
+		start = s.key(opts.Cursor) + "\x00"
+	}
+
+	// etcd rejects an empty key, so list from the first key instead (as
+	// clientv3.WithFromKey does) when there's no prefix at all
+	if start == "" {
+		start = "\x00"
+	}
+
+	// Fetch an extra name to find out if there's another page
+	resp, err := s.client.Get(context.Background(), start,
+		clientv3.WithRange(clientv3.GetPrefixRangeEnd(s.key(opts.Prefix))),
+		clientv3.WithLimit(int64(limit+1)),
+		clientv3.WithKeysOnly(),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		names = append(names, strings.TrimPrefix(string(kv.Key), s.prefix))
+	}
+
+	if len(names) <= limit {
+		return names, "", nil
+	}
+
+	names = names[:limit]
+	return names, names[limit-1], nil
+}
+
+// Delete removes the secret stored under name.
+func (s *Etcd) Delete(name string) error {
+	return s.DeleteContext(context.Background(), name)
+}
+
+// DeleteContext is the same as Delete, but the request is cancelled if ctx is
+// cancelled before it completes.
+func (s *Etcd) DeleteContext(ctx context.Context, name string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	resp, err := s.client.Delete(ctx, s.key(name))
+	if err != nil {
+		return err
+	}
+
+	if resp.Deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Update replaces the secret stored under name, removing any expiry.
+func (s *Etcd) Update(name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Txn(context.Background()).
+		If(clientv3.Compare(clientv3.CreateRevision(s.key(name)), ">", 0)).
+		Then(clientv3.OpPut(s.key(name), string(buf))).
+		Commit()
+
+	if err != nil {
+		return err
+	}
+
+	if !resp.Succeeded {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Upsert stores data under name, replacing any existing secret and removing
+// its expiry.
+func (s *Etcd) Upsert(name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Put(context.Background(), s.key(name), string(buf))
+	return err
+}
+
+// CompareAndSwap replaces the secret stored under name if the current
+// cipher-text matches old, removing any expiry. The secret is only replaced if
+// it has not changed since it was compared.
+func (s *Etcd) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	ctx := context.Background()
+
+	cur, rev, err := s.get(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := checkSwap(cur, old); err != nil {
+		return err
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(s.key(name)), "=", rev)).
+		Then(clientv3.OpPut(s.key(name), string(buf))).
+		Commit()
+
+	if err != nil {
+		return err
+	}
+
+	if !resp.Succeeded {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// Watch delivers an event for each change to the secrets with names starting
+// with prefix, using an etcd watch. Secrets removed by an expired lease are
+// reported as WatchDelete events.
+//
+// Changes are delivered in the order they were made, and none are missed while
+// the watch is running - if the cluster compacts away revisions the watch
+// hasn't yet seen, the watch fails with the compaction error.
+func (s *Etcd) Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error) {
+	// Keep watching through a leader election, but fail if the member is
+	// partitioned from the cluster rather than silently stalling
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+
+	wch := s.client.Watch(ctx, s.key(prefix), clientv3.WithPrefix())
+	out := make(chan WatchEvent)
+
+	go func() {
+		// Stop the etcd watch if delivery ends early
+		defer cancel()
+		defer close(out)
+
+		for resp := range wch {
+			if err := resp.Err(); err != nil {
+				select {
+				case out <- WatchEvent{Err: err}:
+				case <-ctx.Done():
+				}
+
+				return
+			}
+
+			for _, ev := range resp.Events {
+				e := WatchEvent{
+					Type: WatchDelete,
+					Name: strings.TrimPrefix(string(ev.Kv.Key), s.prefix),
+				}
+
+				if ev.Type == clientv3.EventTypePut {
+					d := &encryptor.EncryptedData{}
+					if err := d.UnmarshalBinary(ev.Kv.Value); err != nil {
+						e.Err = err
+					} else {
+						e.Type = WatchPut
+						e.Data = d
+					}
+				}
+
+				select {
+				case out <- e:
+				case <-ctx.Done():
+					return
+				}
+
+				if e.Err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// key returns the etcd key for name.
+func (s *Etcd) key(name string) string {
+	return s.prefix + name
+}
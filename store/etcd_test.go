@@ -0,0 +1,318 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// TestEtcdPutGet ensures the Etcd store behaves the same as Memory, and keeps
+// secrets under the configured prefix.
+func TestEtcdPutGet(t *testing.T) {
+	s, cleanup := newTestEtcd(t, "cryptic/")
+	defer cleanup()
+
+	data := &encryptor.EncryptedData{
+		Ciphertext: []byte("ciphertext"),
+		HMAC:       []byte("hmac"),
+		Type:       encryptor.Nop,
+		Context:    map[string]interface{}{},
+	}
+
+	if err := s.Put("", data); err != ErrInvalidName {
+		t.Errorf("Etcd.Put() empty name error = %v, want %v", err, ErrInvalidName)
+	}
+
+	if _, err := s.Get("secret"); err != ErrNotFound {
+		t.Errorf("Etcd.Get() missing error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.Put("secret", data); err != nil {
+		t.Fatalf("Etcd.Put() error = %v", err)
+	}
+
+	if err := s.Put("secret", data); err != ErrAlreadyExists {
+		t.Errorf("Etcd.Put() existing error = %v, want %v", err, ErrAlreadyExists)
+	}
+
+	if got, err := s.Get("secret"); err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("Etcd.Get() = %v, %v, want %v", got, err, data)
+	}
+
+	if got, err := s.GetMany([]string{"secret", "missing"}); err != nil || len(got) != 1 || !reflect.DeepEqual(got["secret"], data) {
+		t.Errorf("Etcd.GetMany() = %v, %v, want only secret", got, err)
+	}
+
+	resp, err := s.client.Get(context.Background(), "cryptic/secret")
+	if err != nil || resp.Count != 1 {
+		t.Errorf("Etcd.Put() keys = %v, %v, want cryptic/secret", resp, err)
+	}
+
+	if err := s.Delete("secret"); err != nil {
+		t.Errorf("Etcd.Delete() error = %v", err)
+	}
+
+	if err := s.Delete("secret"); err != ErrNotFound {
+		t.Errorf("Etcd.Delete() missing error = %v, want %v", err, ErrNotFound)
+	}
+
+	// The name can be used again once deleted
+	if err := s.Put("secret", data); err != nil {
+		t.Errorf("Etcd.Put() after Delete() error = %v", err)
+	}
+}
+
+// TestEtcdConcurrent ensures only one of many concurrent writers succeeds.
+func TestEtcdConcurrent(t *testing.T) {
+	s, cleanup := newTestEtcd(t, "cryptic/")
+	defer cleanup()
+
+	if got := hammer(func(i int) error {
+		return s.Put("secret", &encryptor.EncryptedData{Ciphertext: []byte{byte(i)}})
+	}); got != 1 {
+		t.Errorf("Etcd.Put() succeeded %d times, want 1", got)
+	}
+
+	if got := hammer(func(i int) error {
+		return s.CompareAndSwap("secret", &encryptor.EncryptedData{Ciphertext: []byte{byte(i)}}, &encryptor.EncryptedData{Ciphertext: []byte("new")})
+	}); got != 1 {
+		t.Errorf("Etcd.CompareAndSwap() succeeded %d times, want 1", got)
+	}
+}
+
+// TestEtcdGetManyChunked ensures GetMany reads more secrets than fit in a
+// single etcd transaction.
+func TestEtcdGetManyChunked(t *testing.T) {
+	s, cleanup := newTestEtcd(t, "cryptic/")
+	defer cleanup()
+
+	names := []string{}
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("secret-%03d", i)
+		names = append(names, name)
+
+		// Leave every third secret missing
+		if i%3 == 0 {
+			continue
+		}
+
+		if err := s.Put(name, &encryptor.EncryptedData{Ciphertext: []byte(name)}); err != nil {
+			t.Fatalf("Etcd.Put(%q) error = %v", name, err)
+		}
+	}
+
+	got, err := s.GetMany(names)
+	if err != nil {
+		t.Fatalf("Etcd.GetMany() error = %v", err)
+	}
+
+	if len(got) != 200 {
+		t.Errorf("Etcd.GetMany() returned %d secrets, want 200", len(got))
+	}
+
+	for name, d := range got {
+		if string(d.Ciphertext) != name {
+			t.Errorf("Etcd.GetMany()[%q] = %q, want %q", name, d.Ciphertext, name)
+		}
+	}
+}
+
+// TestEtcdExpiry ensures secrets stored with a TTL are removed by etcd once the
+// lease expires, and replacing a secret removes its expiry.
+func TestEtcdExpiry(t *testing.T) {
+	s, cleanup := newTestEtcd(t, "cryptic/")
+	defer cleanup()
+
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+
+	if err := s.PutWithTTL("secret", v1, 0); err != ErrInvalidTTL {
+		t.Errorf("Etcd.PutWithTTL(0) error = %v, want %v", err, ErrInvalidTTL)
+	}
+
+	for _, name := range []string{"secret", "replaced"} {
+		if err := s.PutWithTTL(name, v1, time.Second); err != nil {
+			t.Fatalf("Etcd.PutWithTTL(%q) error = %v", name, err)
+		}
+	}
+
+	if err := s.PutWithTTL("secret", v2, time.Second); err != ErrAlreadyExists {
+		t.Errorf("Etcd.PutWithTTL() existing error = %v, want %v", err, ErrAlreadyExists)
+	}
+
+	if err := s.Update("replaced", v2); err != nil {
+		t.Errorf("Etcd.Update() error = %v", err)
+	}
+
+	// Leases are expired by the cluster, so wait for it to happen
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := s.Get("secret"); err == ErrNotFound {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("Etcd.Get() secret did not expire")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if got, err := s.Get("replaced"); err != nil || !bytes.Equal(got.Ciphertext, v2.Ciphertext) {
+		t.Errorf("Etcd.Get() replaced = %v, %v, want %v", got, err, v2)
+	}
+
+	// The name can be used again once expired
+	if err := s.Put("secret", v2); err != nil {
+		t.Errorf("Etcd.Put() after expiry error = %v", err)
+	}
+}
+
+// TestEtcdWatch ensures changes to secrets within the watched prefix are
+// delivered in order, including through a Namespaced store.
+func TestEtcdWatch(t *testing.T) {
+	s, cleanup := newTestEtcd(t, "cryptic/")
+	defer cleanup()
+	namespaced, _ := NewNamespaced(s, "prod")
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+	}{
+		{"Etcd", s},
+		{"Namespaced", namespaced},
+	}
+
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+
+	for _, tt := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		events, err := tt.store.(Watcher).Watch(ctx, "app/")
+		if err != nil {
+			t.Fatalf("%q. Watch() error = %v", tt.name, err)
+		}
+
+		if err := tt.store.Put("other", v1); err != nil {
+			t.Errorf("%q. Put() error = %v", tt.name, err)
+		}
+
+		if err := tt.store.Put("app/key", v1); err != nil {
+			t.Errorf("%q. Put() error = %v", tt.name, err)
+		}
+
+		if err := tt.store.(Updater).Upsert("app/key", v2); err != nil {
+			t.Errorf("%q. Upsert() error = %v", tt.name, err)
+		}
+
+		if err := tt.store.Delete("app/key"); err != nil {
+			t.Errorf("%q. Delete() error = %v", tt.name, err)
+		}
+
+		want := []WatchEvent{
+			{Type: WatchPut, Name: "app/key", Data: v1},
+			{Type: WatchPut, Name: "app/key", Data: v2},
+			{Type: WatchDelete, Name: "app/key"},
+		}
+
+		for i, w := range want {
+			select {
+			case got := <-events:
+				if got.Type != w.Type || got.Name != w.Name || got.Err != nil || (w.Data != nil && !bytes.Equal(got.Data.Ciphertext, w.Data.Ciphertext)) {
+					t.Errorf("%q. Watch() event %d = %+v, want %+v", tt.name, i, got, w)
+				}
+
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%q. Watch() timed out waiting for event %d", tt.name, i)
+			}
+		}
+
+		cancel()
+
+		// The channel is closed once the watch is cancelled
+		for range events {
+		}
+
+		tt.store.Delete("other")
+	}
+
+	hmacBlinder, _ := NewHMACBlinder([]byte("key"))
+	sivBlinder, _ := NewSIVBlinder([]byte("key"))
+
+	if _, err := NewBlinded(s, hmacBlinder).Watch(context.Background(), ""); err != ErrIrreversibleName {
+		t.Errorf("Blinded.Watch() with HMACBlinder error = %v, want %v", err, ErrIrreversibleName)
+	}
+
+	if _, err := NewBlinded(NewMemory(), sivBlinder).Watch(context.Background(), ""); err != ErrWatchUnsupported {
+		t.Errorf("Blinded.Watch() without Watcher error = %v, want %v", err, ErrWatchUnsupported)
+	}
+}
+
+// newTestEtcd returns an Etcd store using prefix, backed by an embedded, single
+// member etcd cluster in a temporary directory, and a func stopping the cluster
+// and removing the directory.
+func newTestEtcd(t *testing.T, prefix string) (*Etcd, func()) {
+	dir, err := ioutil.TempDir("", "cryptic")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+
+	// Listen on any free port
+	local, _ := url.Parse("http://127.0.0.1:0")
+
+	cfg := embed.NewConfig()
+	cfg.Dir = dir
+	// Errors are logged while shutting down
+	cfg.LogLevel = "fatal"
+	cfg.ListenClientUrls = []url.URL{*local}
+	cfg.ListenPeerUrls = []url.URL{*local}
+
+	// Short election timeouts allow the shortest (one second) lease
+	cfg.TickMs = 10
+	cfg.ElectionMs = 100
+
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Failed to start etcd: %s", err)
+	}
+
+	stop := func() {
+		e.Close()
+		os.RemoveAll(dir)
+	}
+
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		stop()
+		t.Fatal("etcd did not start")
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{e.Clients[0].Addr().String()},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		stop()
+		t.Fatalf("Failed to connect to etcd: %s", err)
+	}
+
+	return NewEtcd(client, prefix), func() {
+		client.Close()
+		stop()
+	}
+}
@@ -0,0 +1,16 @@
+package store
+
+import "time"
+
+// timeNow returns the current time, and is replaced in tests to expire secrets
+// without waiting.
+var timeNow = time.Now
+
+// checkTTL returns ErrInvalidTTL if ttl is not a positive duration.
+func checkTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	return nil
+}
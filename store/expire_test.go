@@ -0,0 +1,142 @@
+package store
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+const expiresColumnSQL = `ALTER TABLE secrets ADD COLUMN expires_at INTEGER NULL;`
+
+// TestExpirer ensures expired secrets are treated as missing by each store, and
+// removed by Purge.
+func TestExpirer(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	blinder, _ := NewSIVBlinder([]byte("key"))
+	namespaced, _ := NewNamespaced(NewMemory(), "prod")
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+		// Expected results.
+		wantPurged int
+	}{
+		{"Memory", NewMemory(), 1},
+		{"DB", newTestDB(t, DBOpts{ExpiresColumn: "expires_at"}, expiresColumnSQL), 1},
+		{"Blinded", NewBlinded(NewMemory(), blinder), -1},
+		{"Namespaced", namespaced, -1},
+	}
+
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+
+	for _, tt := range tests {
+		now := time.Now()
+		timeNow = func() time.Time { return now }
+
+		s := tt.store
+		e := s.(Expirer)
+
+		if err := e.PutWithTTL("secret", v1, 0); err != ErrInvalidTTL {
+			t.Errorf("%q. PutWithTTL(0) error = %v, want %v", tt.name, err, ErrInvalidTTL)
+		}
+
+		for _, name := range []string{"secret", "replaced", "gone"} {
+			if err := e.PutWithTTL(name, v1, time.Hour); err != nil {
+				t.Errorf("%q. PutWithTTL(%q) error = %v", tt.name, name, err)
+			}
+		}
+
+		if err := s.Put("forever", v1); err != nil {
+			t.Errorf("%q. Put() error = %v", tt.name, err)
+		}
+
+		if got, err := s.Get("secret"); err != nil || !bytes.Equal(got.Ciphertext, v1.Ciphertext) {
+			t.Errorf("%q. Get() before expiry = %v, %v, want %v", tt.name, got, err, v1)
+		}
+
+		if err := e.PutWithTTL("secret", v2, time.Hour); err == nil {
+			t.Errorf("%q. PutWithTTL() overwrote an existing secret", tt.name)
+		}
+
+		// Replacing a secret removes the expiry
+		if err := s.(Updater).Update("replaced", v2); err != nil {
+			t.Errorf("%q. Update() error = %v", tt.name, err)
+		}
+
+		if err := s.Delete("gone"); err != nil {
+			t.Errorf("%q. Delete() error = %v", tt.name, err)
+		}
+
+		now = now.Add(time.Hour)
+
+		if _, err := s.Get("secret"); err != ErrNotFound {
+			t.Errorf("%q. Get() after expiry error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if got, err := s.Get("replaced"); err != nil || !bytes.Equal(got.Ciphertext, v2.Ciphertext) {
+			t.Errorf("%q. Get() replaced = %v, %v, want %v", tt.name, got, err, v2)
+		}
+
+		if got, err := s.(BulkGetter).GetMany([]string{"secret", "forever"}); err != nil || len(got) != 1 {
+			t.Errorf("%q. GetMany() = %v, %v, want only forever", tt.name, got, err)
+		}
+
+		if got, err := ListAll(s.(Lister), ""); err != nil || !reflect.DeepEqual(sortedCopy(got), []string{"forever", "replaced"}) {
+			t.Errorf("%q. List() = %v, %v, want [forever replaced]", tt.name, got, err)
+		}
+
+		if p, ok := s.(Purger); ok {
+			if got, err := p.Purge(); err != nil || got != tt.wantPurged {
+				t.Errorf("%q. Purge() = %v, %v, want %v", tt.name, got, err, tt.wantPurged)
+			}
+		}
+
+		if err := s.Delete("secret"); err != ErrNotFound {
+			t.Errorf("%q. Delete() after expiry error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		// The name can be used again once expired
+		if err := s.Put("secret", v2); err != nil {
+			t.Errorf("%q. Put() after expiry error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestExpiryUnsupported(t *testing.T) {
+	if err := newTestDB(t, DBOpts{}).PutWithTTL("secret", &encryptor.EncryptedData{}, time.Hour); err != ErrExpiryUnsupported {
+		t.Errorf("DB.PutWithTTL() error = %v, want %v", err, ErrExpiryUnsupported)
+	}
+
+	s := NewBlinded(struct{ Interface }{NewMemory()}, nil)
+	if err := s.PutWithTTL("secret", &encryptor.EncryptedData{}, time.Hour); err != ErrExpiryUnsupported {
+		t.Errorf("Blinded.PutWithTTL() error = %v, want %v", err, ErrExpiryUnsupported)
+	}
+}
+
+// TestMemoryJanitor ensures the janitor is stopped by Close.
+func TestMemoryJanitor(t *testing.T) {
+	s := NewMemory()
+	if err := s.PutWithTTL("secret", &encryptor.EncryptedData{}, time.Hour); err != nil {
+		t.Fatalf("Memory.PutWithTTL() error = %v", err)
+	}
+
+	if s.stop == nil {
+		t.Errorf("Memory.PutWithTTL() did not start the janitor")
+	}
+
+	s.Close()
+
+	if s.stop != nil {
+		t.Errorf("Memory.Close() did not stop the janitor")
+	}
+
+	// Closing twice is harmless
+	s.Close()
+}
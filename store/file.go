@@ -0,0 +1,311 @@
+package store
+
+import (
+	"encoding/base32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// fileExt is appended to the encoded name of each secret file, so temporary
+// and lock files are never mistaken for secrets.
+const fileExt = ".secret"
+
+// fileLockName is the name of the file locked by writers.
+const fileLockName = ".lock"
+
+// fileMaxNameLen is the longest file name supported by most filesystems.
+const fileMaxNameLen = 255
+
+// fileEncoding encodes secret names into file names using only lower case
+// letters and digits, so names that differ only by case never share a file on
+// a case-insensitive filesystem (the default on macOS and Windows).
+var fileEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// File stores each secret in its own file within a directory, readable only by
+// the owner.
+//
+// Secret names are base32 encoded to form the file name, so any name is safe
+// to use (there's no path traversal), but names are limited to 155 bytes.
+//
+// Files are written to a temporary file and renamed into place, so readers
+// never see a partially written secret. Writers hold an advisory lock on the
+// directory, allowing several processes to share a store safely - locking is
+// only effective within a single process on Windows.
+type File struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFile returns an initalised File store, creating dir if it doesn't exist.
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &File{dir: dir}, nil
+}
+
+// Put stores data under the given name.
+func (s *File) Put(name string, data *encryptor.EncryptedData) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Lstat(path); err == nil {
+		return ErrAlreadyExists
+	}
+
+	return s.write(path, data)
+}
+
+// Get fetches the secret stored under name.
+func (s *File) Get(name string) (*encryptor.EncryptedData, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.read(path)
+}
+
+// List returns the names of the secrets in the directory, in lexical order.
+//
+// Every file in the directory is read for each page, so List is slow for very
+// large stores.
+func (s *File) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := []string{}
+	for _, f := range files {
+		name, ok := fileSecretName(f.Name())
+		if !ok {
+			continue
+		}
+
+		if strings.HasPrefix(name, opts.Prefix) && name > opts.Cursor {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	limit := listLimit(opts)
+	if len(names) <= limit {
+		return names, "", nil
+	}
+
+	names = names[:limit]
+	return names, names[limit-1], nil
+}
+
+// Delete removes the secret stored under name.
+func (s *File) Delete(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+
+		return err
+	}
+
+	// Flush the directory entry so the change survives a crash
+	return syncDir(s.dir)
+}
+
+// Update replaces the secret stored under name.
+func (s *File) Update(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		if cur == nil {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// Upsert stores data under name, replacing any existing secret.
+func (s *File) Upsert(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return nil
+	})
+}
+
+// CompareAndSwap replaces the secret stored under name if the current
+// cipher-text matches old.
+func (s *File) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return checkSwap(cur, old)
+	})
+}
+
+// swap stores data under name if check returns nil when passed the current
+// secret (or nil if it does not exist), holding the lock throughout.
+func (s *File) swap(name string, data *encryptor.EncryptedData, check func(cur *encryptor.EncryptedData) error) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cur, err := s.read(path)
+	switch err {
+	case nil, ErrNotFound:
+		break
+
+	default:
+		return err
+	}
+
+	if err := check(cur); err != nil {
+		return err
+	}
+
+	return s.write(path, data)
+}
+
+// path returns the path of the file holding name.
+func (s *File) path(name string) (string, error) {
+	if name == "" {
+		return "", ErrInvalidName
+	}
+
+	file := fileEncoding.EncodeToString([]byte(name)) + fileExt
+	if len(file) > fileMaxNameLen {
+		return "", ErrInvalidName
+	}
+
+	return filepath.Join(s.dir, file), nil
+}
+
+// fileSecretName returns the secret name held in the file named file, or false
+// if the file does not hold a secret.
+func fileSecretName(file string) (string, bool) {
+	if !strings.HasSuffix(file, fileExt) {
+		return "", false
+	}
+
+	name, err := fileEncoding.DecodeString(strings.TrimSuffix(file, fileExt))
+	if err != nil {
+		return "", false
+	}
+
+	return string(name), true
+}
+
+// read decodes the secret held in the file at path.
+func (s *File) read(path string) (*encryptor.EncryptedData, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	d := &encryptor.EncryptedData{}
+	if err := d.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// write atomically replaces the file at path with the encoded data - the data
+// is written to a temporary file, flushed to disk, and renamed into place.
+func (s *File) write(path string, data *encryptor.EncryptedData) error {
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	// Temporary files are created with 0600 permissions
+	f, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+
+	// Remove the temporary file if it isn't renamed
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	// Flush the directory entry so the change survives a crash
+	return syncDir(s.dir)
+}
+
+// lock takes an exclusive advisory lock on the store directory, returning a
+// func to release it.
+func (s *File) lock() (func(), error) {
+	s.mu.Lock()
+
+	f, err := os.OpenFile(filepath.Join(s.dir, fileLockName), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		// Closing the file releases the lock
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
@@ -0,0 +1,17 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package store
+
+import "os"
+
+// lockFile does nothing, as advisory locks are not available on this platform -
+// writers are only serialised within a single process.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// syncDir does nothing, as directories cannot be flushed on this platform.
+func syncDir(path string) error {
+	return nil
+}
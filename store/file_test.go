@@ -0,0 +1,167 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// TestFilePutGet ensures the File store behaves the same as Memory, and any
+// name is kept within the store directory.
+func TestFilePutGet(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		pname string
+		// Expected results.
+		wantErr error
+	}{
+		{"Simple", "secret", nil},
+		{"Empty name", "", ErrInvalidName},
+		{"Path separators", "prod/db/password", nil},
+		{"Parent directory", "../../etc/passwd", nil},
+		{"Dot", ".", nil},
+		{"Lock file name", ".lock", nil},
+		{"Binary", "\x00\xff", nil},
+		{"Lower case", "aaa", nil},
+		{"Differs only by case", "aaG", nil},
+		{"Longest name", strings.Repeat("a", 155), nil},
+		{"Name too long", strings.Repeat("a", 156), ErrInvalidName},
+	}
+
+	s, cleanup := newTestFile(t)
+	defer cleanup()
+	data := &encryptor.EncryptedData{
+		Ciphertext: []byte("ciphertext"),
+		HMAC:       []byte("hmac"),
+		Type:       encryptor.Nop,
+		Context:    map[string]interface{}{},
+	}
+
+	for _, tt := range tests {
+		if err := s.Put(tt.pname, data); err != tt.wantErr {
+			t.Errorf("%q. File.Put() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+
+		got, err := s.Get(tt.pname)
+		if err != tt.wantErr {
+			t.Errorf("%q. File.Get() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+
+		if tt.wantErr != nil {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, data) {
+			t.Errorf("%q. File.Get() = %v, want %v", tt.name, got, data)
+		}
+
+		if err := s.Put(tt.pname, data); err != ErrAlreadyExists {
+			t.Errorf("%q. File.Put() existing error = %v, want %v", tt.name, err, ErrAlreadyExists)
+		}
+	}
+
+	// Every secret is a file directly within the store directory
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+
+	secrets := 0
+	for _, f := range files {
+		if f.Name() == fileLockName {
+			continue
+		}
+
+		if !strings.HasSuffix(f.Name(), fileExt) {
+			t.Errorf("unexpected file %q left in store directory", f.Name())
+		}
+
+		// Names must not collide on a case-insensitive filesystem
+		if f.Name() != strings.ToLower(f.Name()) {
+			t.Errorf("file %q is not lower case", f.Name())
+		}
+
+		if f.Mode().Perm() != 0600 && runtime.GOOS != "windows" {
+			t.Errorf("file %q has permissions %v, want 0600", f.Name(), f.Mode().Perm())
+		}
+
+		secrets++
+	}
+
+	if want := 9; secrets != want {
+		t.Errorf("store directory holds %d secrets, want %d", secrets, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(s.dir, "..", "..", "etc", "passwd"+fileExt)); err == nil {
+		t.Error("secret written outside the store directory")
+	}
+
+	for _, tt := range tests {
+		if err := s.Delete(tt.pname); err != tt.wantErr {
+			t.Errorf("%q. File.Delete() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+
+		if tt.wantErr != nil {
+			continue
+		}
+
+		if _, err := s.Get(tt.pname); err != ErrNotFound {
+			t.Errorf("%q. File.Get() after Delete() error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if err := s.Delete(tt.pname); err != ErrNotFound {
+			t.Errorf("%q. File.Delete() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+	}
+}
+
+// TestFileConcurrent ensures only one of many concurrent writers, each with
+// their own store (as if a separate process), succeeds.
+func TestFileConcurrent(t *testing.T) {
+	s, cleanup := newTestFile(t)
+	defer cleanup()
+
+	if got := hammer(func(i int) error {
+		other, err := NewFile(s.dir)
+		if err != nil {
+			return err
+		}
+
+		return other.Put("secret", &encryptor.EncryptedData{Ciphertext: []byte{byte(i)}})
+	}); got != 1 {
+		t.Errorf("File.Put() succeeded %d times, want 1", got)
+	}
+
+	if got := hammer(func(i int) error {
+		return s.Delete("secret")
+	}); got != 1 {
+		t.Errorf("File.Delete() succeeded %d times, want 1", got)
+	}
+}
+
+// newTestFile returns a File store in a temporary directory, and a func
+// removing the directory.
+func newTestFile(t *testing.T) (*File, func()) {
+	dir, err := ioutil.TempDir("", "cryptic")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+
+	cleanup := func() { os.RemoveAll(dir) }
+
+	s, err := NewFile(filepath.Join(dir, "secrets"))
+	if err != nil {
+		cleanup()
+		t.Fatalf("Failed to initialise File store: %s", err)
+	}
+
+	return s, cleanup
+}
@@ -0,0 +1,60 @@
+package store
+
+// defaultListLimit is the page size used when ListOpts.Limit is not set.
+const defaultListLimit = 100
+
+// ListAll returns the names of every secret in l starting with prefix, fetching
+// each page in turn.
+//
+// Names are returned in the order the store lists them, with any duplicates
+// (possible with Redis) removed.
+func ListAll(l Lister, prefix string) ([]string, error) {
+	seen := map[string]bool{}
+	out := []string{}
+
+	opts := &ListOpts{Prefix: prefix}
+	for {
+		names, cursor, err := l.List(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			out = append(out, name)
+		}
+
+		if cursor == "" {
+			return out, nil
+		}
+
+		opts.Cursor = cursor
+	}
+}
+
+// listLimit returns the page size requested in opts.
+func listLimit(opts *ListOpts) int {
+	if opts.Limit < 1 {
+		return defaultListLimit
+	}
+
+	return opts.Limit
+}
+
+// prefixEnd returns the smallest string greater than every string starting
+// with prefix, or an empty string if there is no such string.
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+
+	return ""
+}
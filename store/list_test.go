@@ -0,0 +1,238 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// TestListPaging ensures every store returns the same names a page at a time.
+func TestListPaging(t *testing.T) {
+	blinder, _ := NewSIVBlinder([]byte("key"))
+
+	file, cleanupFile := newTestFile(t)
+	defer cleanupFile()
+
+	bolt, cleanupBolt := newTestBolt(t, DefaultBoltBucket)
+	defer cleanupBolt()
+
+	etcd, cleanupEtcd := newTestEtcd(t, "cryptic/")
+	defer cleanupEtcd()
+
+	// etcd rejects a range starting at the empty key
+	etcdNoPrefix, cleanupEtcdNoPrefix := newTestEtcd(t, "")
+	defer cleanupEtcdNoPrefix()
+
+	stores := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+		// Expected results.
+		sorted bool
+	}{
+		{"Memory", NewMemory(), true},
+		{"DB", newTestDB(t, DBOpts{}), true},
+		{"File", file, true},
+		{"Bolt", bolt, true},
+		{"S3", newTestS3(), true},
+		{"DynamoDB", newTestDynamoDB(), true},
+		{"Etcd", etcd, true},
+		{"Etcd without prefix", etcdNoPrefix, true},
+		{"Blinded", NewBlinded(NewMemory(), blinder), false},
+	}
+
+	for _, st := range stores {
+		for i := 0; i < 5; i++ {
+			st.store.Put(fmt.Sprintf("prod/%d", i), &encryptor.EncryptedData{})
+			st.store.Put(fmt.Sprintf("dev/%d", i), &encryptor.EncryptedData{})
+		}
+
+		tests := []struct {
+			// Test description.
+			name string
+			// Parameters.
+			prefix string
+			limit  int
+			// Expected results.
+			want []string
+		}{
+			{
+				"All",
+				"",
+				0,
+				[]string{"dev/0", "dev/1", "dev/2", "dev/3", "dev/4", "prod/0", "prod/1", "prod/2", "prod/3", "prod/4"},
+			},
+			{
+				"Prefix",
+				"prod/",
+				0,
+				[]string{"prod/0", "prod/1", "prod/2", "prod/3", "prod/4"},
+			},
+			{
+				"Prefix paged",
+				"prod/",
+				2,
+				[]string{"prod/0", "prod/1", "prod/2", "prod/3", "prod/4"},
+			},
+			{
+				"Exact page",
+				"dev/",
+				5,
+				[]string{"dev/0", "dev/1", "dev/2", "dev/3", "dev/4"},
+			},
+			{
+				"No matches",
+				"staging/",
+				0,
+				[]string{},
+			},
+		}
+
+		for _, tt := range tests {
+			l := st.store.(Lister)
+
+			got := []string{}
+			opts := &ListOpts{Prefix: tt.prefix, Limit: tt.limit}
+			for {
+				names, cursor, err := l.List(opts)
+				if err != nil {
+					t.Fatalf("%q %q. List() error = %v", st.name, tt.name, err)
+				}
+
+				if tt.limit > 0 && len(names) > tt.limit {
+					t.Errorf("%q %q. List() returned %d names, limit %d", st.name, tt.name, len(names), tt.limit)
+				}
+
+				got = append(got, names...)
+
+				if cursor == "" {
+					break
+				}
+				opts.Cursor = cursor
+			}
+
+			if !st.sorted {
+				got = sortedCopy(got)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%q %q. List() = %v, want %v", st.name, tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+// TestDbListCollation ensures DB.List only returns names starting with the
+// exact prefix under a case-insensitive collation, and pages past the rows it
+// skips.
+func TestDbListCollation(t *testing.T) {
+	db := newSQLiteDB(t, `CREATE TABLE secrets(id INTEGER NOT NULL PRIMARY KEY, name TEXT COLLATE NOCASE UNIQUE, data BLOB);`)
+
+	s, err := NewDB(db, &DBOpts{Dialect: SQLite})
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+
+	for _, name := range []string{"PROD/0", "prod/1", "Prod/2", "prod/3", "prod/4", "\u00bf/0", "\u00bf\u00bf/0"} {
+		s.Put(name, &encryptor.EncryptedData{})
+	}
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		prefix string
+		limit  int
+		// Expected results.
+		want []string
+	}{
+		{"Case sensitive", "prod/", 0, []string{"prod/1", "prod/3", "prod/4"}},
+		{"Case sensitive paged", "prod/", 1, []string{"prod/1", "prod/3", "prod/4"}},
+		{"Mixed case", "Prod/", 1, []string{"Prod/2"}},
+		{"Trailing 0xbf", "\u00bf", 1, []string{"\u00bf/0", "\u00bf\u00bf/0"}},
+	}
+
+	for _, tt := range tests {
+		got := []string{}
+		opts := &ListOpts{Prefix: tt.prefix, Limit: tt.limit}
+		for {
+			names, cursor, err := s.List(opts)
+			if err != nil {
+				t.Fatalf("%q. DB.List() error = %v", tt.name, err)
+			}
+
+			got = append(got, names...)
+
+			if cursor == "" {
+				break
+			}
+			opts.Cursor = cursor
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q. DB.List() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListUnsupported(t *testing.T) {
+	hmacBlinder, _ := NewHMACBlinder([]byte("key"))
+	if _, _, err := NewBlinded(NewMemory(), hmacBlinder).List(nil); err != ErrIrreversibleName {
+		t.Errorf("Blinded.List() error = %v, want %v", err, ErrIrreversibleName)
+	}
+
+	sivBlinder, _ := NewSIVBlinder([]byte("key"))
+	s := NewBlinded(struct{ Interface }{NewMemory()}, sivBlinder)
+	if _, _, err := s.List(nil); err != ErrListUnsupported {
+		t.Errorf("Blinded.List() error = %v, want %v", err, ErrListUnsupported)
+	}
+}
+
+func TestListAll(t *testing.T) {
+	s := NewMemory()
+	for i := 0; i < 250; i++ {
+		s.Put(fmt.Sprintf("secret%03d", i), &encryptor.EncryptedData{})
+	}
+
+	got, err := ListAll(s, "secret1")
+	if err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+
+	if len(got) != 100 || got[0] != "secret100" || got[99] != "secret199" {
+		t.Errorf("ListAll() = %v", got)
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		prefix string
+		// Expected results.
+		want string
+	}{
+		{"Simple", "abc", "abd"},
+		{"Trailing 0xff", "ab\xff", "ac"},
+		{"All 0xff", "\xff\xff", ""},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := prefixEnd(tt.prefix); got != tt.want {
+			t.Errorf("%q. prefixEnd() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// sortedCopy returns a sorted copy of names.
+func sortedCopy(names []string) []string {
+	out := append([]string{}, names...)
+	sort.Strings(out)
+	return out
+}
@@ -1,7 +1,11 @@
 package store
 
 import (
+	"context"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/domodwyer/cryptic/encryptor"
 )
@@ -14,16 +18,37 @@ type rwLocker interface {
 
 // Memory is an in-memory data store. Contents are not persisted in any way
 // after the process ends.
+//
+// Storing an expiring secret starts a janitor goroutine to remove expired
+// secrets - call Close to stop it once the store is no longer used.
 type Memory struct {
-	secrets map[string]encryptor.EncryptedData
-	mu      rwLocker
+	secrets  map[string]encryptor.EncryptedData
+	history  map[string][]memoryVersion
+	expires  map[string]time.Time
+	metadata map[string]*Metadata
+	mu       rwLocker
+
+	janitor sync.Once
+	stop    chan struct{}
+}
+
+// memoryJanitorInterval is how often the janitor removes expired secrets.
+const memoryJanitorInterval = time.Minute
+
+// memoryVersion is a single version of a secret.
+type memoryVersion struct {
+	version int
+	data    encryptor.EncryptedData
 }
 
 // NewMemory returns an initalised memory store.
 func NewMemory() *Memory {
 	return &Memory{
-		secrets: map[string]encryptor.EncryptedData{},
-		mu:      &sync.RWMutex{},
+		secrets:  map[string]encryptor.EncryptedData{},
+		history:  map[string][]memoryVersion{},
+		expires:  map[string]time.Time{},
+		metadata: map[string]*Metadata{},
+		mu:       &sync.RWMutex{},
 	}
 }
 
@@ -33,13 +58,51 @@ func (s *Memory) Put(name string, data *encryptor.EncryptedData) error {
 		return ErrInvalidName
 	}
 
-	if _, err := s.Get(name); err != ErrNotFound {
-		return ErrAlreadyExists
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.put(name, data)
+}
+
+// PutWithTTL stores data under the given name, removing it once ttl has
+// passed.
+func (s *Memory) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	if name == "" {
+		return ErrInvalidName
 	}
 
+	if err := checkTTL(ttl); err != nil {
+		return err
+	}
+
+	s.janitor.Do(s.startJanitor)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.put(name, data); err != nil {
+		return err
+	}
+
+	if s.expires == nil {
+		s.expires = map[string]time.Time{}
+	}
+
+	s.expires[name] = timeNow().Add(ttl)
+	return nil
+}
+
+// put stores data under name, unless it already exists.
+//
+// The check is done under the write lock, so a concurrent Put can't sneak in
+// between the check and the write. The caller must hold the lock.
+func (s *Memory) put(name string, data *encryptor.EncryptedData) error {
+	s.reap(name)
+
+	if _, ok := s.secrets[name]; ok {
+		return ErrAlreadyExists
+	}
+
 	s.secrets[name] = *data
 	return nil
 }
@@ -53,7 +116,7 @@ func (s *Memory) Get(name string) (*encryptor.EncryptedData, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	d, ok := s.secrets[name]
+	d, ok := s.secret(name)
 	if !ok {
 		return nil, ErrNotFound
 	}
@@ -61,6 +124,57 @@ func (s *Memory) Get(name string) (*encryptor.EncryptedData, error) {
 	return &d, nil
 }
 
+// GetMany fetches the EncryptedData stored under each of names.
+func (s *Memory) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := map[string]*encryptor.EncryptedData{}
+	for _, name := range names {
+		if name == "" {
+			return nil, ErrInvalidName
+		}
+
+		if d, ok := s.secret(name); ok {
+			out[name] = &d
+		}
+	}
+
+	return out, nil
+}
+
+// List returns the names of the stored secrets in sorted order.
+//
+// The returned cursor is the last name in the page.
+func (s *Memory) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	s.mu.RLock()
+	names := []string{}
+	for name := range s.secrets {
+		if s.expired(name) {
+			continue
+		}
+
+		if strings.HasPrefix(name, opts.Prefix) && name > opts.Cursor {
+			names = append(names, name)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(names)
+
+	limit := listLimit(opts)
+	if len(names) <= limit {
+		return names, "", nil
+	}
+
+	names = names[:limit]
+	return names, names[limit-1], nil
+}
+
 // Delete removes a secret from the memory store.
 func (s *Memory) Delete(name string) error {
 	if name == "" {
@@ -70,10 +184,392 @@ func (s *Memory) Delete(name string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.reap(name)
+
 	if _, ok := s.secrets[name]; !ok {
 		return ErrNotFound
 	}
 
-	delete(s.secrets, name)
+	s.remove(name)
+	return nil
+}
+
+// Update replaces the secret stored under name.
+func (s *Memory) Update(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		if cur == nil {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// Upsert stores data under name, replacing any existing secret.
+func (s *Memory) Upsert(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return nil
+	})
+}
+
+// CompareAndSwap replaces the secret stored under name if the current
+// cipher-text matches old.
+func (s *Memory) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return checkSwap(cur, old)
+	})
+}
+
+// swap stores data under name if check returns nil when passed the current
+// secret (or nil if it does not exist).
+func (s *Memory) swap(name string, data *encryptor.EncryptedData, check func(cur *encryptor.EncryptedData) error) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reap(name)
+
+	var cur *encryptor.EncryptedData
+	if d, ok := s.secrets[name]; ok {
+		cur = &d
+	}
+
+	if err := check(cur); err != nil {
+		return err
+	}
+
+	if _, ok := s.history[name]; ok {
+		s.putVersion(name, data)
+		return nil
+	}
+
+	s.secrets[name] = *data
+	delete(s.expires, name)
 	return nil
 }
+
+// PutVersion stores data as the next version of name.
+func (s *Memory) PutVersion(name string, data *encryptor.EncryptedData) (int, error) {
+	if name == "" {
+		return 0, ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.putVersion(name, data), nil
+}
+
+// CompareAndSwapVersion stores data as the next version of name, if the latest
+// version is version.
+func (s *Memory) CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error) {
+	if name == "" {
+		return 0, ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := s.versionsOf(name)
+	if len(h) == 0 {
+		return 0, ErrNotFound
+	}
+
+	if h[len(h)-1].version != version {
+		return 0, ErrConflict
+	}
+
+	return s.putVersion(name, data), nil
+}
+
+// putVersion stores data as the next version of name, returning the new
+// version number.
+//
+// The caller must hold the lock.
+func (s *Memory) putVersion(name string, data *encryptor.EncryptedData) int {
+	s.reap(name)
+
+	h := s.versionsOf(name)
+
+	v := 1
+	if len(h) > 0 {
+		v = h[len(h)-1].version + 1
+	}
+
+	if s.history == nil {
+		s.history = map[string][]memoryVersion{}
+	}
+
+	s.history[name] = append(h, memoryVersion{v, *data})
+	s.secrets[name] = *data
+	delete(s.expires, name)
+
+	return v
+}
+
+// GetVersion fetches the given version of name.
+func (s *Memory) GetVersion(name string, version int) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	if version < 1 {
+		return nil, ErrInvalidVersion
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, h := range s.versionsOf(name) {
+		if h.version == version {
+			d := h.data
+			return &d, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Versions returns the version numbers held for name.
+func (s *Memory) Versions(name string) ([]int, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	h := s.versionsOf(name)
+	if len(h) == 0 {
+		return nil, ErrNotFound
+	}
+
+	out := make([]int, len(h))
+	for i := range h {
+		out[i] = h[i].version
+	}
+
+	return out, nil
+}
+
+// Prune removes all but the keep most recent versions of name.
+func (s *Memory) Prune(name string, keep int) (int, error) {
+	if name == "" {
+		return 0, ErrInvalidName
+	}
+
+	if keep < 1 {
+		return 0, ErrInvalidVersion
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := s.versionsOf(name)
+	if len(h) == 0 {
+		return 0, ErrNotFound
+	}
+
+	if len(h) <= keep {
+		return 0, nil
+	}
+
+	removed := len(h) - keep
+	s.history[name] = append([]memoryVersion{}, h[removed:]...)
+
+	return removed, nil
+}
+
+// versionsOf returns the versions of name in ascending order. Secrets stored
+// with Put have no history, and are returned as version 1.
+//
+// The caller must hold the lock.
+func (s *Memory) versionsOf(name string) []memoryVersion {
+	if s.expired(name) {
+		return nil
+	}
+
+	if h, ok := s.history[name]; ok {
+		return h
+	}
+
+	if d, ok := s.secrets[name]; ok {
+		return []memoryVersion{{1, d}}
+	}
+
+	return nil
+}
+
+// PutMetadata stores m as the metadata of the secret stored under name.
+func (s *Memory) PutMetadata(name string, m *Metadata) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reap(name)
+
+	if _, ok := s.secrets[name]; !ok {
+		return ErrNotFound
+	}
+
+	if s.metadata == nil {
+		s.metadata = map[string]*Metadata{}
+	}
+
+	s.metadata[name] = m.clone()
+	return nil
+}
+
+// GetMetadata fetches the metadata of the secret stored under name.
+func (s *Memory) GetMetadata(name string) (*Metadata, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.secret(name); !ok {
+		return nil, ErrNotFound
+	}
+
+	m, ok := s.metadata[name]
+	if !ok {
+		return nil, ErrNoMetadata
+	}
+
+	return m.clone(), nil
+}
+
+// Purge removes all expired secrets, returning the number removed.
+func (s *Memory) Purge() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for name := range s.expires {
+		if s.reap(name) {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// Close stops the janitor goroutine, if running.
+func (s *Memory) Close() error {
+	s.janitor.Do(func() {})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+
+	return nil
+}
+
+// startJanitor starts a goroutine calling Purge every memoryJanitorInterval
+// until Close is called.
+func (s *Memory) startJanitor() {
+	stop := make(chan struct{})
+
+	s.mu.Lock()
+	s.stop = stop
+	s.mu.Unlock()
+
+	go func() {
+		t := time.NewTicker(memoryJanitorInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-t.C:
+				s.Purge()
+
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// secret returns the secret stored under name, unless it has expired.
+//
+// The caller must hold the lock.
+func (s *Memory) secret(name string) (encryptor.EncryptedData, bool) {
+	if s.expired(name) {
+		return encryptor.EncryptedData{}, false
+	}
+
+	d, ok := s.secrets[name]
+	return d, ok
+}
+
+// expired returns true if name has an expiry that has passed.
+//
+// The caller must hold the lock.
+func (s *Memory) expired(name string) bool {
+	t, ok := s.expires[name]
+	return ok && !timeNow().Before(t)
+}
+
+// reap removes name if it has expired, returning true if it was removed.
+//
+// The caller must hold the write lock.
+func (s *Memory) reap(name string) bool {
+	if !s.expired(name) {
+		return false
+	}
+
+	s.remove(name)
+	return true
+}
+
+// remove deletes name, and any history or expiry.
+//
+// The caller must hold the write lock.
+func (s *Memory) remove(name string) {
+	delete(s.secrets, name)
+	delete(s.history, name)
+	delete(s.expires, name)
+	delete(s.metadata, name)
+}
+
+// PutContext stores data under the given name, unless ctx is already
+// cancelled.
+func (s *Memory) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.Put(name, data)
+}
+
+// GetContext fetches the EncryptedData stored under name, unless ctx is already
+// cancelled.
+func (s *Memory) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.Get(name)
+}
+
+// DeleteContext removes a secret from the memory store, unless ctx is already
+// cancelled.
+func (s *Memory) DeleteContext(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.Delete(name)
+}
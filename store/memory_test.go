@@ -2,6 +2,7 @@ package store
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/domodwyer/cryptic/encryptor"
@@ -236,3 +237,88 @@ func TestMemoryPut(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryGetMany(t *testing.T) {
+	lock := &mockLock{}
+
+	s := &Memory{
+		secrets: map[string]encryptor.EncryptedData{
+			"kings": {Ciphertext: []byte("a 🐐")},
+			"bugs":  {Ciphertext: []byte("oh noes! 💣")},
+		},
+		mu: lock,
+	}
+
+	got, err := s.GetMany([]string{"kings", "missing", "bugs"})
+	if err != nil {
+		t.Fatalf("Memory.GetMany() error = %v", err)
+	}
+
+	want := map[string]*encryptor.EncryptedData{
+		"kings": {Ciphertext: []byte("a 🐐")},
+		"bugs":  {Ciphertext: []byte("oh noes! 💣")},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Memory.GetMany() = %v, want %v", got, want)
+	}
+
+	if _, err := s.GetMany([]string{"kings", ""}); err != ErrInvalidName {
+		t.Errorf("Memory.GetMany() error = %v, want %v", err, ErrInvalidName)
+	}
+
+	// Ensure we always release our locks
+	if lock.isLocked || lock.isRLocked {
+		t.Errorf("Memory.GetMany() is still locked!")
+	}
+}
+
+// TestMemoryConcurrent ensures only one of many concurrent Put and Delete calls
+// for the same name succeeds.
+func TestMemoryConcurrent(t *testing.T) {
+	s := NewMemory()
+
+	if got := hammer(func(i int) error {
+		return s.Put("secret", &encryptor.EncryptedData{Ciphertext: []byte{byte(i)}})
+	}); got != 1 {
+		t.Errorf("Memory.Put() succeeded %d times, want 1", got)
+	}
+
+	if got := hammer(func(i int) error {
+		return s.Delete("secret")
+	}); got != 1 {
+		t.Errorf("Memory.Delete() succeeded %d times, want 1", got)
+	}
+}
+
+// hammer calls fn from many goroutines at once, returning the number of calls
+// that succeeded.
+func hammer(fn func(i int) error) int {
+	const workers = 50
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		ok    int
+		start = make(chan struct{})
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			if err := fn(i); err == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return ok
+}
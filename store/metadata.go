@@ -0,0 +1,114 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Metadata describes a secret - who created it, when, and why.
+//
+// Metadata is stored unencrypted so it can be read without access to the
+// encryption keys, but is authenticated with an HMAC (see Sign) so changes made
+// directly in the backend are detected.
+type Metadata struct {
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	CreatedBy   string            `json:"created_by"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+
+	// MAC authenticates the metadata, and the name of the secret it describes.
+	MAC []byte `json:"mac,omitempty"`
+}
+
+// Describer is implemented by stores able to hold Metadata for each secret.
+//
+// Metadata is removed (or expires) along with the secret it describes.
+// GetMetadata returns ErrNotFound if the secret does not exist, and
+// ErrNoMetadata if it exists without metadata.
+//
+// Stores do not check the MAC - call Verify on the returned Metadata.
+type Describer interface {
+	PutMetadata(name string, m *Metadata) error
+	GetMetadata(name string) (*Metadata, error)
+}
+
+// Sign sets MAC to an HMAC-SHA256 of the metadata and name using key, binding
+// the metadata to the secret it describes.
+func (m *Metadata) Sign(key []byte, name string) error {
+	mac, err := m.mac(key, name)
+	if err != nil {
+		return err
+	}
+
+	m.MAC = mac
+	return nil
+}
+
+// Verify returns ErrInvalidMetadata unless MAC was created by Sign with the
+// same key and name.
+func (m *Metadata) Verify(key []byte, name string) error {
+	mac, err := m.mac(key, name)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(mac, m.MAC) {
+		return ErrInvalidMetadata
+	}
+
+	return nil
+}
+
+// HasLabels returns true if m has every one of labels.
+func (m *Metadata) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if got, ok := m.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mac computes the HMAC of name and every field but MAC.
+func (m *Metadata) mac(key []byte, name string) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrMetadataKeyTooShort
+	}
+
+	c := *m
+	c.MAC = nil
+
+	// Maps are encoded with sorted keys, so the encoding is stable
+	buf, err := json.Marshal(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hmac.New(sha256.New, key)
+
+	// Prefix the name with its length, so it can't run into the metadata
+	fmt.Fprintf(h, "%d:%s", len(name), name)
+	h.Write(buf)
+
+	return h.Sum(nil), nil
+}
+
+// clone returns a deep copy of m.
+func (m *Metadata) clone() *Metadata {
+	c := *m
+
+	if m.Labels != nil {
+		c.Labels = make(map[string]string, len(m.Labels))
+		for k, v := range m.Labels {
+			c.Labels[k] = v
+		}
+	}
+
+	c.MAC = append([]byte(nil), m.MAC...)
+	return &c
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+const metadataColumnSQL = `ALTER TABLE secrets ADD COLUMN metadata TEXT NULL;`
+
+// TestMetadataVerify ensures any change to the metadata, or using it for a
+// different secret, fails verification.
+func TestMetadataVerify(t *testing.T) {
+	signed := func() *Metadata {
+		m := &Metadata{
+			CreatedAt:   time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC),
+			UpdatedAt:   time.Date(2017, 3, 2, 12, 0, 0, 0, time.UTC),
+			CreatedBy:   "dom@host",
+			Description: "Stripe live key",
+			Labels:      map[string]string{"env": "prod"},
+		}
+
+		m.Sign([]byte("key"), "stripe")
+		return m
+	}
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		change func(m *Metadata)
+		key    string
+		pname  string
+		// Expected results.
+		wantErr error
+	}{
+		{
+			"OK",
+			func(m *Metadata) {},
+			"key",
+			"stripe",
+			nil,
+		},
+		{
+			"Wrong key",
+			func(m *Metadata) {},
+			"other",
+			"stripe",
+			ErrInvalidMetadata,
+		},
+		{
+			"Wrong name",
+			func(m *Metadata) {},
+			"key",
+			"other",
+			ErrInvalidMetadata,
+		},
+		{
+			"Changed description",
+			func(m *Metadata) { m.Description = "Test key" },
+			"key",
+			"stripe",
+			ErrInvalidMetadata,
+		},
+		{
+			"Changed label",
+			func(m *Metadata) { m.Labels["env"] = "dev" },
+			"key",
+			"stripe",
+			ErrInvalidMetadata,
+		},
+		{
+			"Changed creator",
+			func(m *Metadata) { m.CreatedBy = "mallory@host" },
+			"key",
+			"stripe",
+			ErrInvalidMetadata,
+		},
+		{
+			"No MAC",
+			func(m *Metadata) { m.MAC = nil },
+			"key",
+			"stripe",
+			ErrInvalidMetadata,
+		},
+		{
+			"No key",
+			func(m *Metadata) {},
+			"",
+			"stripe",
+			ErrMetadataKeyTooShort,
+		},
+	}
+
+	for _, tt := range tests {
+		m := signed()
+		tt.change(m)
+
+		if err := m.Verify([]byte(tt.key), tt.pname); err != tt.wantErr {
+			t.Errorf("%q. Metadata.Verify() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestDescriber ensures each store keeps the metadata of a secret for as long
+// as the secret exists.
+func TestDescriber(t *testing.T) {
+	blinder, _ := NewSIVBlinder([]byte("key"))
+	namespaced, _ := NewNamespaced(NewMemory(), "prod")
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+	}{
+		{"Memory", NewMemory()},
+		{"DB", newTestDB(t, DBOpts{MetadataColumn: "metadata"}, metadataColumnSQL)},
+		{"Blinded", NewBlinded(NewMemory(), blinder)},
+		{"Namespaced", namespaced},
+	}
+
+	key := []byte("key")
+
+	for _, tt := range tests {
+		s := tt.store
+		d := s.(Describer)
+
+		m := &Metadata{
+			CreatedAt: time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC),
+			CreatedBy: "dom@host",
+			Labels:    map[string]string{"env": "prod"},
+		}
+		m.Sign(key, "secret")
+
+		if err := d.PutMetadata("secret", m); err != ErrNotFound {
+			t.Errorf("%q. PutMetadata() missing secret error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if err := s.Put("secret", &encryptor.EncryptedData{}); err != nil {
+			t.Errorf("%q. Put() error = %v", tt.name, err)
+			continue
+		}
+
+		if _, err := d.GetMetadata("secret"); err != ErrNoMetadata {
+			t.Errorf("%q. GetMetadata() error = %v, want %v", tt.name, err, ErrNoMetadata)
+		}
+
+		if err := d.PutMetadata("secret", m); err != nil {
+			t.Errorf("%q. PutMetadata() error = %v", tt.name, err)
+		}
+
+		got, err := d.GetMetadata("secret")
+		if err != nil {
+			t.Errorf("%q. GetMetadata() error = %v", tt.name, err)
+			continue
+		}
+
+		if err := got.Verify(key, "secret"); err != nil {
+			t.Errorf("%q. GetMetadata() verify error = %v", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(got.Labels, m.Labels) || !got.CreatedAt.Equal(m.CreatedAt) {
+			t.Errorf("%q. GetMetadata() = %+v, want %+v", tt.name, got, m)
+		}
+
+		// Changing the returned metadata must not change the stored copy
+		got.Labels["env"] = "dev"
+		if again, _ := d.GetMetadata("secret"); again.Labels["env"] != "prod" {
+			t.Errorf("%q. GetMetadata() returned the stored metadata", tt.name)
+		}
+
+		if err := s.Delete("secret"); err != nil {
+			t.Errorf("%q. Delete() error = %v", tt.name, err)
+		}
+
+		if _, err := d.GetMetadata("secret"); err != ErrNotFound {
+			t.Errorf("%q. GetMetadata() after Delete() error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		// A new secret with the same name starts without metadata
+		s.Put("secret", &encryptor.EncryptedData{})
+		if _, err := d.GetMetadata("secret"); err != ErrNoMetadata {
+			t.Errorf("%q. GetMetadata() new secret error = %v, want %v", tt.name, err, ErrNoMetadata)
+		}
+	}
+}
+
+func TestHasLabels(t *testing.T) {
+	m := &Metadata{Labels: map[string]string{"env": "prod", "team": "payments"}}
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		labels map[string]string
+		// Expected results.
+		want bool
+	}{
+		{"None", map[string]string{}, true},
+		{"One", map[string]string{"env": "prod"}, true},
+		{"All", map[string]string{"env": "prod", "team": "payments"}, true},
+		{"Wrong value", map[string]string{"env": "dev"}, false},
+		{"Missing", map[string]string{"owner": "dom"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.HasLabels(tt.labels); got != tt.want {
+			t.Errorf("%q. Metadata.HasLabels() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDbMetadataDisabled(t *testing.T) {
+	s := newTestDB(t, DBOpts{})
+
+	if err := s.PutMetadata("secret", &Metadata{}); err != ErrMetadataUnsupported {
+		t.Errorf("DB.PutMetadata() error = %v, want %v", err, ErrMetadataUnsupported)
+	}
+}
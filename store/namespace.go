@@ -0,0 +1,356 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// NamespaceSeparator separates the namespace from the secret name in the keys
+// of a Namespaced store.
+const NamespaceSeparator = ":"
+
+// Namespaced wraps a store, prefixing every secret name with Namespace so
+// several teams or environments can share a single backend without their
+// secrets colliding.
+//
+// Listing and deleting are confined to the namespace - secrets in other
+// namespaces are never visible.
+type Namespaced struct {
+	Store     Interface
+	Namespace string
+}
+
+// NewNamespaced returns an initalised Namespaced store, wrapping s.
+//
+// The namespace must not be empty, or contain NamespaceSeparator.
+func NewNamespaced(s Interface, namespace string) (*Namespaced, error) {
+	if namespace == "" || strings.Contains(namespace, NamespaceSeparator) {
+		return nil, ErrInvalidNamespace
+	}
+
+	return &Namespaced{
+		Store:     s,
+		Namespace: namespace,
+	}, nil
+}
+
+// Put stores data under the namespaced name.
+func (s *Namespaced) Put(name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	return s.Store.Put(s.key(name), data)
+}
+
+// PutWithTTL stores data under the namespaced name, expiring it after ttl.
+func (s *Namespaced) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	e, ok := s.Store.(Expirer)
+	if !ok {
+		return ErrExpiryUnsupported
+	}
+
+	return e.PutWithTTL(s.key(name), data, ttl)
+}
+
+// PutMetadata stores m as the metadata of the namespaced name.
+func (s *Namespaced) PutMetadata(name string, m *Metadata) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	d, ok := s.Store.(Describer)
+	if !ok {
+		return ErrMetadataUnsupported
+	}
+
+	return d.PutMetadata(s.key(name), m)
+}
+
+// GetMetadata fetches the metadata of the namespaced name.
+func (s *Namespaced) GetMetadata(name string) (*Metadata, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	d, ok := s.Store.(Describer)
+	if !ok {
+		return nil, ErrMetadataUnsupported
+	}
+
+	return d.GetMetadata(s.key(name))
+}
+
+// PutContext stores data under the namespaced name, returning early if ctx is
+// cancelled.
+func (s *Namespaced) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	return WithContext(s.Store).PutContext(ctx, s.key(name), data)
+}
+
+// Get fetches the secret stored under the namespaced name.
+func (s *Namespaced) Get(name string) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	return s.Store.Get(s.key(name))
+}
+
+// GetContext fetches the secret stored under the namespaced name, returning
+// early if ctx is cancelled.
+func (s *Namespaced) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	return WithContext(s.Store).GetContext(ctx, s.key(name))
+}
+
+// GetMany fetches the named secrets from the namespace in a single operation.
+// If the underlying store doesn't implement BulkGetter, ErrBulkUnsupported is
+// returned.
+func (s *Namespaced) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	bulk, ok := s.Store.(BulkGetter)
+	if !ok {
+		return nil, ErrBulkUnsupported
+	}
+
+	keys := make([]string, len(names))
+	for i, name := range names {
+		if name == "" {
+			return nil, ErrInvalidName
+		}
+
+		keys[i] = s.key(name)
+	}
+
+	res, err := bulk.GetMany(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]*encryptor.EncryptedData{}
+	for i, k := range keys {
+		if d, ok := res[k]; ok {
+			out[names[i]] = d
+		}
+	}
+
+	return out, nil
+}
+
+// List returns the names of the secrets in the namespace, with the namespace
+// removed.
+//
+// If the underlying store doesn't implement Lister, ErrListUnsupported is
+// returned.
+func (s *Namespaced) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	l, ok := s.Store.(Lister)
+	if !ok {
+		return nil, "", ErrListUnsupported
+	}
+
+	keys, cursor, err := l.List(&ListOpts{
+		Prefix: s.key(opts.Prefix),
+		Limit:  opts.Limit,
+		Cursor: opts.Cursor,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	prefix := s.key("")
+
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		// Stores may match more loosely than a prefix (i.e. case-insensitive
+		// database collations) - never return secrets from another namespace
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		names = append(names, strings.TrimPrefix(k, prefix))
+	}
+
+	return names, cursor, nil
+}
+
+// Watch watches the secrets in the namespace with names starting with prefix -
+// changes to secrets in other namespaces are never delivered.
+//
+// If the underlying store doesn't implement Watcher, ErrWatchUnsupported is
+// returned.
+func (s *Namespaced) Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error) {
+	w, ok := s.Store.(Watcher)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+
+	events, err := w.Watch(ctx, s.key(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	ns := s.key("")
+
+	return mapWatch(ctx, events, func(key string) (string, bool) {
+		if !strings.HasPrefix(key, ns) {
+			return "", false
+		}
+
+		return strings.TrimPrefix(key, ns), true
+	}), nil
+}
+
+// Delete removes the secret stored under the namespaced name.
+func (s *Namespaced) Delete(name string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	return s.Store.Delete(s.key(name))
+}
+
+// DeleteContext removes the secret stored under the namespaced name, returning
+// early if ctx is cancelled.
+func (s *Namespaced) DeleteContext(ctx context.Context, name string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	return WithContext(s.Store).DeleteContext(ctx, s.key(name))
+}
+
+// PutVersion stores data as the next version of the namespaced name.
+func (s *Namespaced) PutVersion(name string, data *encryptor.EncryptedData) (int, error) {
+	v, err := s.versioner(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.PutVersion(s.key(name), data)
+}
+
+// GetVersion fetches the given version of the namespaced name.
+func (s *Namespaced) GetVersion(name string, version int) (*encryptor.EncryptedData, error) {
+	v, err := s.versioner(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.GetVersion(s.key(name), version)
+}
+
+// Versions returns the versions held for the namespaced name.
+func (s *Namespaced) Versions(name string) ([]int, error) {
+	v, err := s.versioner(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.Versions(s.key(name))
+}
+
+// Prune removes all but the keep most recent versions of the namespaced name.
+func (s *Namespaced) Prune(name string, keep int) (int, error) {
+	v, err := s.versioner(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Prune(s.key(name), keep)
+}
+
+// CompareAndSwapVersion stores data as the next version of the namespaced
+// name, if the latest version is version.
+func (s *Namespaced) CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error) {
+	if name == "" {
+		return 0, ErrInvalidName
+	}
+
+	v, ok := s.Store.(VersionSwapper)
+	if !ok {
+		return 0, ErrVersioningUnsupported
+	}
+
+	return v.CompareAndSwapVersion(s.key(name), version, data)
+}
+
+// Update replaces the secret stored under the namespaced name.
+func (s *Namespaced) Update(name string, data *encryptor.EncryptedData) error {
+	u, err := s.updater(name)
+	if err != nil {
+		return err
+	}
+
+	return u.Update(s.key(name), data)
+}
+
+// Upsert stores data under the namespaced name, replacing any existing secret.
+func (s *Namespaced) Upsert(name string, data *encryptor.EncryptedData) error {
+	u, err := s.updater(name)
+	if err != nil {
+		return err
+	}
+
+	return u.Upsert(s.key(name), data)
+}
+
+// CompareAndSwap replaces the secret stored under the namespaced name if its
+// cipher-text matches old.
+func (s *Namespaced) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	u, err := s.updater(name)
+	if err != nil {
+		return err
+	}
+
+	return u.CompareAndSwap(s.key(name), old, data)
+}
+
+// updater validates name, returning the underlying store as an Updater.
+func (s *Namespaced) updater(name string) (Updater, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	u, ok := s.Store.(Updater)
+	if !ok {
+		return nil, ErrUpdateUnsupported
+	}
+
+	return u, nil
+}
+
+// versioner validates name, returning the underlying store as a Versioner.
+func (s *Namespaced) versioner(name string) (Versioner, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	v, ok := s.Store.(Versioner)
+	if !ok {
+		return nil, ErrVersioningUnsupported
+	}
+
+	return v, nil
+}
+
+// key returns the underlying store key for name.
+func (s *Namespaced) key(name string) string {
+	return s.Namespace + NamespaceSeparator + name
+}
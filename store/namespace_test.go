@@ -0,0 +1,105 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// TestNamespaced ensures secrets in one namespace are never visible to
+// another sharing the same backend.
+func TestNamespaced(t *testing.T) {
+	mem := NewMemory()
+	prod, _ := NewNamespaced(mem, "prod")
+	dev, _ := NewNamespaced(mem, "dev")
+
+	prodData := &encryptor.EncryptedData{Ciphertext: []byte("prod")}
+	devData := &encryptor.EncryptedData{Ciphertext: []byte("dev")}
+
+	if err := prod.Put("api_key", prodData); err != nil {
+		t.Fatalf("prod Put() error = %v", err)
+	}
+
+	if err := dev.Put("api_key", devData); err != nil {
+		t.Fatalf("dev Put() error = %v, secrets collided", err)
+	}
+
+	if _, ok := mem.secrets["prod:api_key"]; !ok {
+		t.Errorf("Put() did not prefix the name with the namespace")
+	}
+
+	got, err := prod.Get("api_key")
+	if err != nil || !reflect.DeepEqual(got, prodData) {
+		t.Errorf("prod Get() = %v, %v, want %v", got, err, prodData)
+	}
+
+	many, err := dev.GetMany([]string{"api_key", "missing"})
+	if err != nil || !reflect.DeepEqual(many, map[string]*encryptor.EncryptedData{"api_key": devData}) {
+		t.Errorf("dev GetMany() = %v, %v", many, err)
+	}
+
+	single, _ := NewNamespaced(struct{ Interface }{mem}, "dev")
+	if _, err := single.GetMany([]string{"api_key"}); err != ErrBulkUnsupported {
+		t.Errorf("GetMany() without BulkGetter error = %v, want %v", err, ErrBulkUnsupported)
+	}
+
+	mem.Put("api_key", &encryptor.EncryptedData{})
+	mem.Put("prodx:api_key", &encryptor.EncryptedData{})
+
+	names, err := ListAll(prod, "")
+	if err != nil || !reflect.DeepEqual(names, []string{"api_key"}) {
+		t.Errorf("prod List() = %v, %v, want [api_key]", names, err)
+	}
+
+	if err := dev.Delete("api_key"); err != nil {
+		t.Errorf("dev Delete() error = %v", err)
+	}
+
+	if err := dev.Delete("api_key"); err != ErrNotFound {
+		t.Errorf("dev Delete() again error = %v, want %v", err, ErrNotFound)
+	}
+
+	if _, err := prod.Get("api_key"); err != nil {
+		t.Errorf("prod Get() after dev Delete() error = %v", err)
+	}
+}
+
+func TestNewNamespaced(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		namespace string
+		// Expected results.
+		wantErr error
+	}{
+		{"OK", "team/prod", nil},
+		{"Empty", "", ErrInvalidNamespace},
+		{"Separator", "team:prod", ErrInvalidNamespace},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewNamespaced(NewMemory(), tt.namespace); err != tt.wantErr {
+			t.Errorf("%q. NewNamespaced() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestNamespacedBlinded ensures blinded names are listed only within the
+// namespace.
+func TestNamespacedBlinded(t *testing.T) {
+	blinder, _ := NewSIVBlinder([]byte("key"))
+	mem := NewMemory()
+
+	prod, _ := NewNamespaced(mem, "prod")
+	dev, _ := NewNamespaced(mem, "dev")
+
+	NewBlinded(prod, blinder).Put("prod_secret", &encryptor.EncryptedData{})
+	NewBlinded(dev, blinder).Put("dev_secret", &encryptor.EncryptedData{})
+
+	names, err := ListAll(NewBlinded(prod, blinder), "")
+	if err != nil || !reflect.DeepEqual(names, []string{"prod_secret"}) {
+		t.Errorf("Blinded List() = %v, %v, want [prod_secret]", names, err)
+	}
+}
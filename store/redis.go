@@ -1,6 +1,11 @@
 package store
 
 import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/domodwyer/cryptic/encryptor"
@@ -19,9 +24,31 @@ type Redis struct {
 type redisInterface interface {
 	Get(key string) *redis.StringCmd
 	Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetNX(key string, value interface{}, expiration time.Duration) *redis.BoolCmd
 	Del(keys ...string) *redis.IntCmd
+	MGet(keys ...string) *redis.SliceCmd
+	Scan(cursor uint64, match string, count int64) redis.Scanner
+	HGet(key, field string) *redis.StringCmd
+	HKeys(key string) *redis.StringSliceCmd
+	HDel(key string, fields ...string) *redis.IntCmd
 }
 
+// redisHistorySuffix is appended to a secret name to form the key of the hash
+// holding its versions.
+//
+// The hash maps each version number to the secret, with the latest version
+// number held in the "latest" field. The latest version is also stored under
+// the plain secret name, so Get doesn't pay for versioning.
+const redisHistorySuffix = "\x00history"
+
+// redisMetadataSuffix is appended to a secret name to form the key holding its
+// JSON encoded Metadata. The key expires along with the secret.
+const redisMetadataSuffix = "\x00metadata"
+
+// redisMaxRetries is the number of times a transaction is retried when another
+// client changes the secret concurrently.
+const redisMaxRetries = 5
+
 // NewRedis returns an initalised Redis store.
 func NewRedis(opts *redis.Options) *Redis {
 	return &Redis{
@@ -30,25 +57,40 @@ func NewRedis(opts *redis.Options) *Redis {
 }
 
 // Put stores the given secret in redis, with no expiration set.
+//
+// The secret is written with SET NX, so redis atomically refuses to overwrite
+// an existing secret even when several clients race to store the same name.
 func (s *Redis) Put(name string, data *encryptor.EncryptedData) error {
-	if name == "" {
-		return ErrInvalidName
+	return s.put(name, data, 0)
+}
+
+// PutWithTTL stores the given secret in redis, using the native redis key
+// expiry to remove it once ttl has passed.
+func (s *Redis) PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	if err := checkTTL(ttl); err != nil {
+		return err
 	}
 
-	// TODO: the Get() and Put() should be done in a transaction using the redis
-	// WATCH command to avoid race conditions - opportunistic locking.
+	return s.put(name, data, ttl)
+}
 
-	// Ensure we're not overwriting something
-	if _, err := s.Get(name); err != ErrNotFound {
-		return ErrAlreadyExists
+// put stores the secret with SET NX, expiring it after ttl if non-zero.
+func (s *Redis) put(name string, data *encryptor.EncryptedData, ttl time.Duration) error {
+	if name == "" || redisReserved(name) {
+		return ErrInvalidName
 	}
 
 	// Because EncryptedData implements BinaryMarshaller, we can pass it
 	// directly to redis - the redis library will marshal it for us.
-	if err := s.Redis.Set(name, data, 0).Err(); err != nil {
+	ok, err := s.Redis.SetNX(name, data, ttl).Result()
+	if err != nil {
 		return err
 	}
 
+	if !ok {
+		return ErrAlreadyExists
+	}
+
 	return nil
 }
 
@@ -80,17 +122,558 @@ func (s *Redis) Get(name string) (*encryptor.EncryptedData, error) {
 	return d, nil
 }
 
-// Delete removes the secret from redis.
+// GetMany fetches the secrets from redis using a single MGET command.
+func (s *Redis) GetMany(names []string) (map[string]*encryptor.EncryptedData, error) {
+	out := map[string]*encryptor.EncryptedData{}
+	if len(names) == 0 {
+		return out, nil
+	}
+
+	for _, name := range names {
+		if name == "" {
+			return nil, ErrInvalidName
+		}
+	}
+
+	vals, err := s.Redis.MGet(names...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range vals {
+		// Missing keys are returned as nil
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		d := &encryptor.EncryptedData{}
+		if err := d.UnmarshalBinary([]byte(str)); err != nil {
+			return nil, err
+		}
+
+		out[names[i]] = d
+	}
+
+	return out, nil
+}
+
+// List returns the names of the secrets in redis using SCAN, so the server is
+// never blocked by listing large databases.
+//
+// Limit is passed to redis as the COUNT hint, so a page may hold more or fewer
+// names than Limit (or none at all) and names may be repeated across pages -
+// ListAll removes any duplicates. Keep going until the returned cursor is
+// empty.
+func (s *Redis) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	var cursor uint64
+	if opts.Cursor != "" {
+		var err error
+		cursor, err = strconv.ParseUint(opts.Cursor, 10, 64)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	match := redisGlobEscaper.Replace(opts.Prefix) + "*"
+
+	keys, next, err := s.Redis.Scan(cursor, match, int64(listLimit(opts))).Result()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Skip the version history and metadata keys
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if !redisReserved(k) {
+			names = append(names, k)
+		}
+	}
+
+	if next == 0 {
+		return names, "", nil
+	}
+
+	return names, strconv.FormatUint(next, 10), nil
+}
+
+// redisGlobEscaper escapes the characters redis treats as special in a MATCH
+// pattern.
+var redisGlobEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`*`, `\*`,
+	`?`, `\?`,
+	`[`, `\[`,
+	`]`, `\]`,
+)
+
+// redisReserved returns true if name contains a suffix used for the keys
+// related to a secret, such as the version history.
+func redisReserved(name string) bool {
+	return strings.Contains(name, redisHistorySuffix) || strings.Contains(name, redisMetadataSuffix)
+}
+
+// Delete removes the secret, and any version history or metadata, from redis.
+//
+// The number of keys removed by DEL tells us if the secret existed, so there's
+// no window for another client to delete it between checking and deleting.
 func (s *Redis) Delete(name string) error {
-	// TODO: Transaction same as Put()
-	if _, err := s.Get(name); err != nil {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	n, err := s.Redis.Del(name, name+redisHistorySuffix, name+redisMetadataSuffix).Result()
+	if err != nil {
+		return err
+	}
+
+	// The history and metadata never exist without the secret itself
+	if n == 0 {
 		return ErrNotFound
 	}
 
-	resp := s.Redis.Del(name)
+	return nil
+}
+
+// PutVersion stores data as the next version of name, using a WATCH
+// transaction to ensure concurrent writers never create the same version.
+func (s *Redis) PutVersion(name string, data *encryptor.EncryptedData) (int, error) {
+	return s.putVersion(name, data, 0)
+}
+
+// CompareAndSwapVersion stores data as the next version of name, if the latest
+// version is version.
+func (s *Redis) CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error) {
+	if version < 1 {
+		return 0, ErrConflict
+	}
+
+	return s.putVersion(name, data, version)
+}
+
+// putVersion stores data as the next version of name. If expect is non-zero,
+// ErrConflict is returned unless the latest version is expect.
+func (s *Redis) putVersion(name string, data *encryptor.EncryptedData, expect int) (int, error) {
+	if name == "" || redisReserved(name) {
+		return 0, ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	hkey := name + redisHistorySuffix
+
+	var version int
+	err = s.watch(func(tx *redis.Tx) error {
+		latest, err := redisLatest(tx, name)
+		if err != nil {
+			return err
+		}
+
+		// Secrets stored with Put have no history - record the existing value
+		// as version 1 first
+		var first []byte
+		if latest == 0 {
+			cur, err := s.getFrom(tx, name)
+			switch err {
+			case nil:
+				latest = 1
+				if first, err = cur.MarshalBinary(); err != nil {
+					return err
+				}
+
+			case ErrNotFound:
+				if expect > 0 {
+					return ErrNotFound
+				}
+
+			default:
+				return err
+			}
+		}
+
+		if expect > 0 && latest != expect {
+			return ErrConflict
+		}
+
+		version = latest + 1
+
+		_, err = tx.MultiExec(func() error {
+			if first != nil {
+				tx.HSet(hkey, "1", string(first))
+			}
+
+			tx.HSet(hkey, strconv.Itoa(version), string(buf))
+			tx.HSet(hkey, "latest", strconv.Itoa(version))
+			tx.Set(name, data, 0)
+
+			// Setting the secret removes any expiry, so the metadata must
+			// no longer expire either
+			tx.Persist(name + redisMetadataSuffix)
+			return nil
+		})
+
+		return err
+	}, name, hkey)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// Update replaces the secret stored under name.
+func (s *Redis) Update(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		if cur == nil {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// Upsert stores data under name, replacing any existing secret.
+func (s *Redis) Upsert(name string, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return nil
+	})
+}
+
+// CompareAndSwap replaces the secret stored under name if the current
+// cipher-text matches old.
+func (s *Redis) CompareAndSwap(name string, old, data *encryptor.EncryptedData) error {
+	return s.swap(name, data, func(cur *encryptor.EncryptedData) error {
+		return checkSwap(cur, old)
+	})
+}
+
+// swap stores data under name within a WATCH transaction, if check returns nil
+// when passed the current secret (or nil if it does not exist).
+func (s *Redis) swap(name string, data *encryptor.EncryptedData, check func(cur *encryptor.EncryptedData) error) error {
+	if name == "" || redisReserved(name) {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	hkey := name + redisHistorySuffix
+
+	return s.watch(func(tx *redis.Tx) error {
+		cur, err := s.getFrom(tx, name)
+		switch err {
+		case nil:
+			break
+
+		case ErrNotFound:
+			cur = nil
+
+		default:
+			return err
+		}
+
+		if err := check(cur); err != nil {
+			return err
+		}
+
+		latest, err := redisLatest(tx, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.MultiExec(func() error {
+			// Record the new value as the next version if name has a history
+			if latest > 0 {
+				tx.HSet(hkey, strconv.Itoa(latest+1), string(buf))
+				tx.HSet(hkey, "latest", strconv.Itoa(latest+1))
+			}
+
+			tx.Set(name, data, 0)
+
+			// Setting the secret removes any expiry, so the metadata must
+			// no longer expire either
+			tx.Persist(name + redisMetadataSuffix)
+			return nil
+		})
+
+		return err
+	}, name, hkey)
+}
+
+// PutMetadata stores m as the metadata of the secret stored under name, expiring
+// it along with the secret.
+func (s *Redis) PutMetadata(name string, m *Metadata) error {
+	if name == "" || redisReserved(name) {
+		return ErrInvalidName
+	}
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	mkey := name + redisMetadataSuffix
+
+	return s.watch(func(tx *redis.Tx) error {
+		if _, err := s.getFrom(tx, name); err != nil {
+			return err
+		}
+
+		// PTTL is negative if the secret doesn't expire
+		ttl, err := tx.PTTL(name).Result()
+		if err != nil {
+			return err
+		}
+
+		if ttl < 0 {
+			ttl = 0
+		}
+
+		_, err = tx.MultiExec(func() error {
+			tx.Set(mkey, string(buf), ttl)
+			return nil
+		})
+
+		return err
+	}, name, mkey)
+}
+
+// GetMetadata fetches the metadata of the secret stored under name.
+func (s *Redis) GetMetadata(name string) (*Metadata, error) {
+	if name == "" || redisReserved(name) {
+		return nil, ErrInvalidName
+	}
+
+	vals, err := s.Redis.MGet(name, name+redisMetadataSuffix).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// Missing keys are returned as nil
+	if len(vals) != 2 || vals[0] == nil {
+		return nil, ErrNotFound
+	}
+
+	str, ok := vals[1].(string)
+	if !ok {
+		return nil, ErrNoMetadata
+	}
+
+	m := &Metadata{}
+	if err := json.Unmarshal([]byte(str), m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// watch calls fn within a WATCH of keys, retrying if any of keys are changed
+// by another client before fn commits its transaction.
+func (s *Redis) watch(fn func(tx *redis.Tx) error, keys ...string) error {
+	// Watch is only available on a client connection, not a pipeline or
+	// transaction
+	c, ok := s.Redis.(watcher)
+	if !ok {
+		return ErrNoTransactions
+	}
+
+	for i := 0; i < redisMaxRetries; i++ {
+		err := c.Watch(fn, keys...)
+		if err == redis.TxFailedErr {
+			continue
+		}
+
+		return err
+	}
+
+	return redis.TxFailedErr
+}
+
+// GetVersion fetches the given version of name.
+func (s *Redis) GetVersion(name string, version int) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	if version < 1 {
+		return nil, ErrInvalidVersion
+	}
+
+	resp := s.Redis.HGet(name+redisHistorySuffix, strconv.Itoa(version))
+	if err := resp.Err(); err != nil {
+		b, _ := resp.Bytes()
+		if len(b) > 0 {
+			return nil, err
+		}
+
+		// Secrets stored with Put are version 1, and have no history
+		if version != 1 {
+			return nil, ErrNotFound
+		}
+
+		if _, err := s.Redis.HGet(name+redisHistorySuffix, "latest").Result(); err == nil {
+			return nil, ErrNotFound
+		}
+
+		return s.Get(name)
+	}
+
+	d := &encryptor.EncryptedData{}
+	if err := resp.Scan(d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Versions returns the version numbers held for name.
+func (s *Redis) Versions(name string) ([]int, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	versions, err := s.versions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) > 0 {
+		return versions, nil
+	}
+
+	// No history, but the secret may have been stored with Put
+	if _, err := s.Get(name); err != nil {
+		return nil, err
+	}
+
+	return []int{1}, nil
+}
+
+// Prune removes all but the keep most recent versions of name.
+func (s *Redis) Prune(name string, keep int) (int, error) {
+	if name == "" {
+		return 0, ErrInvalidName
+	}
+
+	if keep < 1 {
+		return 0, ErrInvalidVersion
+	}
+
+	versions, err := s.Versions(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(versions) <= keep {
+		return 0, nil
+	}
+
+	fields := []string{}
+	for _, v := range versions[:len(versions)-keep] {
+		fields = append(fields, strconv.Itoa(v))
+	}
+
+	if err := s.Redis.HDel(name+redisHistorySuffix, fields...).Err(); err != nil {
+		return 0, err
+	}
+
+	return len(fields), nil
+}
+
+// versions returns the version numbers in the history hash of name, in
+// ascending order.
+func (s *Redis) versions(name string) ([]int, error) {
+	fields, err := s.Redis.HKeys(name + redisHistorySuffix).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []int{}
+	for _, f := range fields {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			// The "latest" field
+			continue
+		}
+
+		versions = append(versions, v)
+	}
+
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// getFrom fetches the secret stored under name using c.
+func (s *Redis) getFrom(c redisInterface, name string) (*encryptor.EncryptedData, error) {
+	return (&Redis{Redis: c}).Get(name)
+}
+
+// watcher is implemented by redis clients supporting optimistic locking.
+type watcher interface {
+	Watch(fn func(*redis.Tx) error, keys ...string) error
+}
+
+// redisLatest returns the latest version number recorded in the history of
+// name, or 0 if it has no history.
+func redisLatest(c redisInterface, name string) (int, error) {
+	resp := c.HGet(name+redisHistorySuffix, "latest")
 	if err := resp.Err(); err != nil {
+		b, _ := resp.Bytes()
+		if len(b) < 1 {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return strconv.Atoi(resp.Val())
+}
+
+// PutContext stores the given secret in redis, returning early if ctx is
+// cancelled.
+//
+// The redis client does not support cancellation, so the secret may still be
+// stored after PutContext returns a context error.
+func (s *Redis) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	return RunContext(ctx, func() error {
+		return s.Put(name, data)
+	})
+}
+
+// GetContext fetches the secret from redis, returning early if ctx is
+// cancelled.
+func (s *Redis) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	var data *encryptor.EncryptedData
+
+	err := RunContext(ctx, func() error {
+		var err error
+		data, err = s.Get(name)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return data, nil
+}
+
+// DeleteContext removes the secret from redis, returning early if ctx is
+// cancelled.
+//
+// As with PutContext, the secret may still be removed after DeleteContext
+// returns a context error.
+func (s *Redis) DeleteContext(ctx context.Context, name string) error {
+	return RunContext(ctx, func() error {
+		return s.Delete(name)
+	})
 }
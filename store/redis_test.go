@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/domodwyer/cryptic/encryptor"
 	"gopkg.in/redis.v4"
@@ -188,3 +189,239 @@ func TestRedisDelete(t *testing.T) {
 		return
 	}
 }
+
+func TestRedisList(t *testing.T) {
+
+	// If we don't have a host to connect to, skip all the redis integration
+	// tests
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("no REDIS_HOST environment variable set, skipping integration tests")
+	}
+
+	c := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_HOST"),
+	})
+
+	keys := []string{"integration_list*1", "integration_list*2", "integration_listX"}
+	defer c.Del(keys...)
+
+	s := Redis{Redis: c}
+	for _, k := range keys {
+		if err := s.Put(k, &encryptor.EncryptedData{}); err != nil {
+			t.Errorf("redis: setting up integration test key: %s", err)
+			return
+		}
+	}
+
+	// The * in the prefix must be matched literally
+	got, err := ListAll(&s, "integration_list*")
+	if err != nil {
+		t.Errorf("Redis.List() error = %v", err)
+		return
+	}
+
+	want := []string{"integration_list*1", "integration_list*2"}
+	if !reflect.DeepEqual(sortedCopy(got), want) {
+		t.Errorf("Redis.List() = %v, want %v", got, want)
+	}
+}
+
+func TestRedisVersions(t *testing.T) {
+
+	// If we don't have a host to connect to, skip all the redis integration
+	// tests
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("no REDIS_HOST environment variable set, skipping integration tests")
+	}
+
+	c := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_HOST"),
+	})
+
+	s := Redis{Redis: c}
+	s.Delete("integration_versions")
+	defer s.Delete("integration_versions")
+
+	if err := s.Put("integration_versions", &encryptor.EncryptedData{Ciphertext: []byte("v1")}); err != nil {
+		t.Errorf("redis: setting up integration test key: %s", err)
+		return
+	}
+
+	if v, err := s.PutVersion("integration_versions", &encryptor.EncryptedData{Ciphertext: []byte("v2")}); err != nil || v != 2 {
+		t.Errorf("Redis.PutVersion() = %v, %v, want 2", v, err)
+	}
+
+	got, err := s.GetVersion("integration_versions", 1)
+	if err != nil || string(got.Ciphertext) != "v1" {
+		t.Errorf("Redis.GetVersion(1) = %v, %v, want v1", got, err)
+	}
+
+	got, err = s.Get("integration_versions")
+	if err != nil || string(got.Ciphertext) != "v2" {
+		t.Errorf("Redis.Get() = %v, %v, want v2", got, err)
+	}
+
+	if removed, err := s.Prune("integration_versions", 1); err != nil || removed != 1 {
+		t.Errorf("Redis.Prune() = %v, %v, want 1", removed, err)
+	}
+
+	if versions, err := s.Versions("integration_versions"); err != nil || !reflect.DeepEqual(versions, []int{2}) {
+		t.Errorf("Redis.Versions() = %v, %v, want [2]", versions, err)
+	}
+
+	// The history hash must not be listed
+	names, err := ListAll(&s, "integration_versions")
+	if err != nil || !reflect.DeepEqual(names, []string{"integration_versions"}) {
+		t.Errorf("Redis.List() = %v, %v, want [integration_versions]", names, err)
+	}
+}
+
+func TestRedisUpdate(t *testing.T) {
+
+	// If we don't have a host to connect to, skip all the redis integration
+	// tests
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("no REDIS_HOST environment variable set, skipping integration tests")
+	}
+
+	c := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_HOST"),
+	})
+
+	s := Redis{Redis: c}
+	s.Delete("integration_update")
+	defer s.Delete("integration_update")
+
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+
+	if err := s.Update("integration_update", v1); err != ErrNotFound {
+		t.Errorf("Redis.Update() missing error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.Upsert("integration_update", v1); err != nil {
+		t.Errorf("Redis.Upsert() error = %v", err)
+		return
+	}
+
+	if err := s.CompareAndSwap("integration_update", v2, v2); err != ErrConflict {
+		t.Errorf("Redis.CompareAndSwap() stale error = %v, want %v", err, ErrConflict)
+	}
+
+	if err := s.CompareAndSwap("integration_update", v1, v2); err != nil {
+		t.Errorf("Redis.CompareAndSwap() error = %v", err)
+	}
+
+	got, err := s.Get("integration_update")
+	if err != nil || string(got.Ciphertext) != "v2" {
+		t.Errorf("Redis.Get() = %v, %v, want v2", got, err)
+	}
+}
+
+func TestRedisConcurrent(t *testing.T) {
+
+	// If we don't have a host to connect to, skip all the redis integration
+	// tests
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("no REDIS_HOST environment variable set, skipping integration tests")
+	}
+
+	c := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_HOST"),
+	})
+
+	s := Redis{Redis: c}
+	c.Del("integration_concurrent")
+	defer c.Del("integration_concurrent")
+
+	if got := hammer(func(i int) error {
+		return s.Put("integration_concurrent", &encryptor.EncryptedData{Ciphertext: []byte{byte(i)}})
+	}); got != 1 {
+		t.Errorf("Redis.Put() succeeded %d times, want 1", got)
+	}
+
+	if got := hammer(func(i int) error {
+		return s.Delete("integration_concurrent")
+	}); got != 1 {
+		t.Errorf("Redis.Delete() succeeded %d times, want 1", got)
+	}
+}
+
+func TestRedisPutWithTTL(t *testing.T) {
+
+	// If we don't have a host to connect to, skip all the redis integration
+	// tests
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("no REDIS_HOST environment variable set, skipping integration tests")
+	}
+
+	c := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_HOST"),
+	})
+
+	s := Redis{Redis: c}
+	c.Del("integration_ttl")
+	defer c.Del("integration_ttl")
+
+	if err := s.PutWithTTL("integration_ttl", &encryptor.EncryptedData{}, time.Second); err != nil {
+		t.Errorf("Redis.PutWithTTL() error = %v", err)
+		return
+	}
+
+	if _, err := s.Get("integration_ttl"); err != nil {
+		t.Errorf("Redis.Get() before expiry error = %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	if _, err := s.Get("integration_ttl"); err != ErrNotFound {
+		t.Errorf("Redis.Get() after expiry error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestRedisMetadata(t *testing.T) {
+
+	// If we don't have a host to connect to, skip all the redis integration
+	// tests
+	if os.Getenv("REDIS_HOST") == "" {
+		t.Skip("no REDIS_HOST environment variable set, skipping integration tests")
+	}
+
+	c := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("REDIS_HOST"),
+	})
+
+	s := Redis{Redis: c}
+	s.Delete("integration_metadata")
+	defer s.Delete("integration_metadata")
+
+	if err := s.PutWithTTL("integration_metadata", &encryptor.EncryptedData{}, time.Minute); err != nil {
+		t.Errorf("redis: setting up integration test key: %s", err)
+		return
+	}
+
+	if _, err := s.GetMetadata("integration_metadata"); err != ErrNoMetadata {
+		t.Errorf("Redis.GetMetadata() error = %v, want %v", err, ErrNoMetadata)
+	}
+
+	m := &Metadata{CreatedBy: "dom@host", Labels: map[string]string{"env": "prod"}}
+	if err := s.PutMetadata("integration_metadata", m); err != nil {
+		t.Errorf("Redis.PutMetadata() error = %v", err)
+	}
+
+	got, err := s.GetMetadata("integration_metadata")
+	if err != nil || !reflect.DeepEqual(got.Labels, m.Labels) {
+		t.Errorf("Redis.GetMetadata() = %v, %v, want %v", got, err, m)
+	}
+
+	// The metadata expires along with the secret
+	if ttl, err := c.PTTL("integration_metadata" + redisMetadataSuffix).Result(); err != nil || ttl <= 0 {
+		t.Errorf("Redis.PutMetadata() ttl = %v, %v, want > 0", ttl, err)
+	}
+
+	// The metadata key must not be listed
+	names, err := ListAll(&s, "integration_metadata")
+	if err != nil || !reflect.DeepEqual(names, []string{"integration_metadata"}) {
+		t.Errorf("Redis.List() = %v, %v, want [integration_metadata]", names, err)
+	}
+}
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// S3 stores each secret as an object in an Amazon S3 (or S3 compatible, such
+// as MinIO) bucket, with the key Prefix followed by the secret name.
+//
+// Put uses a conditional write (If-None-Match) so an existing secret is never
+// overwritten, which needs a provider supporting conditional writes.
+type S3 struct {
+	svc    s3Interface
+	bucket string
+	prefix string
+	sse    string
+	kmsKey string
+}
+
+type s3Interface interface {
+	PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error)
+	GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error)
+	HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error)
+	DeleteObjectWithContext(ctx aws.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error)
+	ListObjectsV2WithContext(ctx aws.Context, input *s3.ListObjectsV2Input, opts ...request.Option) (*s3.ListObjectsV2Output, error)
+}
+
+// S3Opts configures the S3 store.
+type S3Opts struct {
+	Bucket string
+	Region string
+
+	// Prefix is prepended to each secret name to form the object key, such as
+	// "cryptic/".
+	Prefix string
+
+	// Endpoint is the URL of an S3 compatible service, such as MinIO. Path
+	// style addressing is used when Endpoint is set.
+	Endpoint string
+
+	// ServerSideEncryption is the server-side encryption applied to each
+	// object - "AES256", "aws:kms", or empty to use the bucket default.
+	ServerSideEncryption string
+
+	// KMSKeyID is the KMS key used when ServerSideEncryption is "aws:kms",
+	// or empty to use the AWS managed key.
+	KMSKeyID string
+}
+
+// NewS3 returns an initialised S3 store.
+func NewS3(opts *S3Opts) (*S3, error) {
+	if opts.Bucket == "" {
+		return nil, ErrNoBucket
+	}
+
+	cfg := &aws.Config{Region: aws.String(opts.Region)}
+	if opts.Endpoint != "" {
+		cfg.Endpoint = aws.String(opts.Endpoint)
+		cfg.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	return &S3{
+		svc:    s3.New(session.New(), cfg),
+		bucket: opts.Bucket,
+		prefix: opts.Prefix,
+		sse:    opts.ServerSideEncryption,
+		kmsKey: opts.KMSKeyID,
+	}, nil
+}
+
+// Put stores data under the given name.
+func (s *S3) Put(name string, data *encryptor.EncryptedData) error {
+	return s.PutContext(context.Background(), name, data)
+}
+
+// PutContext is the same as Put, but the request is cancelled if ctx is
+// cancelled before it completes.
+func (s *S3) PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.key(name)),
+		Body:        bytes.NewReader(buf),
+		ContentType: aws.String("application/octet-stream"),
+	}
+
+	if s.sse != "" {
+		input.ServerSideEncryption = aws.String(s.sse)
+	}
+
+	if s.kmsKey != "" {
+		input.SSEKMSKeyId = aws.String(s.kmsKey)
+	}
+
+	// Only create the object if the key is unused
+	_, err = s.svc.PutObjectWithContext(ctx, input, request.WithSetRequestHeaders(map[string]string{
+		"If-None-Match": "*",
+	}))
+
+	switch s3Status(err) {
+	case http.StatusPreconditionFailed:
+		return ErrAlreadyExists
+
+	case http.StatusConflict:
+		// A concurrent conditional write of the same key is in progress
+		return ErrConflict
+
+	default:
+		return err
+	}
+}
+
+// Get fetches the secret stored under name.
+func (s *S3) Get(name string) (*encryptor.EncryptedData, error) {
+	return s.GetContext(context.Background(), name)
+}
+
+// GetContext is the same as Get, but the request is cancelled if ctx is
+// cancelled before it completes.
+func (s *S3) GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	resp, err := s.svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		return nil, s3NotFound(err)
+	}
+	defer resp.Body.Close()
+
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &encryptor.EncryptedData{}
+	if err := d.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// List returns the names of the secrets under the configured prefix, in
+// lexical order.
+func (s *S3) List(opts *ListOpts) ([]string, string, error) {
+	if opts == nil {
+		opts = &ListOpts{}
+	}
+
+	limit := listLimit(opts)
+
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(s.key(opts.Prefix)),
+		MaxKeys: aws.Int64(int64(limit)),
+	}
+
+	if opts.Cursor != "" {
+		input.StartAfter = aws.String(s.key(opts.Cursor))
+	}
+
+	resp, err := s.svc.ListObjectsV2WithContext(context.Background(), input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	names := []string{}
+	for _, obj := range resp.Contents {
+		names = append(names, strings.TrimPrefix(aws.StringValue(obj.Key), s.prefix))
+	}
+
+	if !aws.BoolValue(resp.IsTruncated) || len(names) == 0 {
+		return names, "", nil
+	}
+
+	return names, names[len(names)-1], nil
+}
+
+// Delete removes the secret stored under name.
+func (s *S3) Delete(name string) error {
+	return s.DeleteContext(context.Background(), name)
+}
+
+// DeleteContext is the same as Delete, but the requests are cancelled if ctx is
+// cancelled before they complete.
+//
+// S3 doesn't report whether a deleted object existed, so the object is checked
+// first - a concurrent Delete of the same secret may succeed for both callers.
+func (s *S3) DeleteContext(ctx context.Context, name string) error {
+	if name == "" {
+		return ErrInvalidName
+	}
+
+	_, err := s.svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		return s3NotFound(err)
+	}
+
+	_, err = s.svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+
+	return err
+}
+
+// key returns the object key for name.
+func (s *S3) key(name string) string {
+	return s.prefix + name
+}
+
+// s3Status returns the HTTP status code of a failed S3 request, or 0 if err is
+// not from a request.
+func s3Status(err error) int {
+	if e, ok := err.(awserr.RequestFailure); ok {
+		return e.StatusCode()
+	}
+
+	return 0
+}
+
+// s3NotFound returns ErrNotFound if err reports a missing object, or err
+// unchanged.
+func s3NotFound(err error) error {
+	if e, ok := err.(awserr.Error); ok {
+		switch e.Code() {
+		case s3.ErrCodeNoSuchKey:
+			return ErrNotFound
+
+		case s3.ErrCodeNoSuchBucket:
+			return err
+		}
+	}
+
+	// HEAD responses have no body, so only the status code is available
+	if s3Status(err) == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	return err
+}
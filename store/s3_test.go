@@ -0,0 +1,171 @@
+package store
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// mockS3 is an in-memory S3 bucket, honouring If-None-Match for PutObject.
+type mockS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    []*s3.PutObjectInput
+}
+
+func (m *mockS3) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	r := &request.Request{HTTPRequest: &http.Request{Header: http.Header{}}}
+	for _, o := range opts {
+		o(r)
+	}
+
+	buf, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.puts = append(m.puts, input)
+
+	key := aws.StringValue(input.Key)
+	if _, ok := m.objects[key]; ok && r.HTTPRequest.Header.Get("If-None-Match") == "*" {
+		return nil, awserr.NewRequestFailure(awserr.New("PreconditionFailed", "At least one of the pre-conditions you specified did not hold", nil), 412, "")
+	}
+
+	m.objects[key] = buf
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf, ok := m.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.NewRequestFailure(awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil), 404, "")
+	}
+
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(buf))}, nil
+}
+
+func (m *mockS3) HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.objects[aws.StringValue(input.Key)]; !ok {
+		return nil, awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), 404, "")
+	}
+
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (m *mockS3) DeleteObjectWithContext(ctx aws.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.objects, aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (m *mockS3) ListObjectsV2WithContext(ctx aws.Context, input *s3.ListObjectsV2Input, opts ...request.Option) (*s3.ListObjectsV2Output, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := []string{}
+	for k := range m.objects {
+		if strings.HasPrefix(k, aws.StringValue(input.Prefix)) && k > aws.StringValue(input.StartAfter) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	out := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(false)}
+	if max := int(aws.Int64Value(input.MaxKeys)); len(keys) > max {
+		keys = keys[:max]
+		out.IsTruncated = aws.Bool(true)
+	}
+
+	for _, k := range keys {
+		out.Contents = append(out.Contents, &s3.Object{Key: aws.String(k)})
+	}
+
+	return out, nil
+}
+
+// TestS3 ensures the S3 store behaves the same as Memory, and keeps secrets
+// under the configured prefix.
+func TestS3(t *testing.T) {
+	mock := &mockS3{objects: map[string][]byte{}}
+	s := &S3{svc: mock, bucket: "bucket", prefix: "cryptic/", sse: "aws:kms", kmsKey: "key-id"}
+
+	data := &encryptor.EncryptedData{
+		Ciphertext: []byte("ciphertext"),
+		HMAC:       []byte("hmac"),
+		Type:       encryptor.Nop,
+		Context:    map[string]interface{}{},
+	}
+
+	if err := s.Put("", data); err != ErrInvalidName {
+		t.Errorf("S3.Put() empty name error = %v, want %v", err, ErrInvalidName)
+	}
+
+	if _, err := s.Get("secret"); err != ErrNotFound {
+		t.Errorf("S3.Get() missing error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := s.Put("secret", data); err != nil {
+		t.Fatalf("S3.Put() error = %v", err)
+	}
+
+	if err := s.Put("secret", data); err != ErrAlreadyExists {
+		t.Errorf("S3.Put() existing error = %v, want %v", err, ErrAlreadyExists)
+	}
+
+	if got, err := s.Get("secret"); err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("S3.Get() = %v, %v, want %v", got, err, data)
+	}
+
+	if _, ok := mock.objects["cryptic/secret"]; !ok {
+		t.Errorf("S3.Put() objects = %v, want cryptic/secret", mock.objects)
+	}
+
+	put := mock.puts[0]
+	if aws.StringValue(put.Bucket) != "bucket" || aws.StringValue(put.ServerSideEncryption) != "aws:kms" || aws.StringValue(put.SSEKMSKeyId) != "key-id" {
+		t.Errorf("S3.Put() input = %+v, want bucket with aws:kms encryption using key-id", put)
+	}
+
+	if err := s.Delete("secret"); err != nil {
+		t.Errorf("S3.Delete() error = %v", err)
+	}
+
+	if err := s.Delete("secret"); err != ErrNotFound {
+		t.Errorf("S3.Delete() missing error = %v, want %v", err, ErrNotFound)
+	}
+
+	if _, err := NewS3(&S3Opts{}); err != ErrNoBucket {
+		t.Errorf("NewS3() without bucket error = %v, want %v", err, ErrNoBucket)
+	}
+}
+
+// newTestS3 returns an S3 store backed by an in-memory mock.
+func newTestS3() *S3 {
+	return &S3{
+		svc:    &mockS3{objects: map[string][]byte{}},
+		bucket: "bucket",
+		prefix: "cryptic/",
+	}
+}
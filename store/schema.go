@@ -0,0 +1,217 @@
+package store
+
+import (
+	"fmt"
+)
+
+// migration is a single, numbered change to the DB store schema.
+type migration struct {
+	version     int
+	description string
+
+	// enabled reports whether the migration applies to the store - migrations
+	// for optional features are skipped (and not recorded) until the feature
+	// is enabled.
+	enabled func(s *DB) bool
+
+	// repeat runs apply every time Migrate is called, even once recorded.
+	// Migrations that depend on a configurable name (such as the version table
+	// or expiry column) repeat, so renaming it creates the new one.
+	repeat bool
+
+	// apply makes the change. Tables or columns that already exist, such as
+	// those created by hand, are left as they are.
+	apply func(s *DB) error
+}
+
+// migrations are applied in order, and must never be renumbered or removed -
+// add a new migration to change the schema.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create secrets table",
+		enabled:     func(s *DB) bool { return true },
+		apply:       createSecretsTable,
+	},
+	{
+		version:     2,
+		description: "create version table",
+		enabled:     func(s *DB) bool { return s.history != "" },
+		repeat:      true,
+		apply:       createVersionTable,
+	},
+	{
+		version:     3,
+		description: "add expires column",
+		enabled:     func(s *DB) bool { return s.expires != "" },
+		repeat:      true,
+		apply:       func(s *DB) error { return s.addColumn(s.names.expires, "bigint") },
+	},
+	{
+		version:     4,
+		description: "add metadata column",
+		enabled:     func(s *DB) bool { return s.metadata != "" },
+		repeat:      true,
+		apply:       func(s *DB) error { return s.addColumn(s.names.metadata, "text") },
+	},
+}
+
+// Migrate creates the tables used by the store if they do not exist, and
+// applies any schema changes needed by the enabled features (such as adding
+// the expiry column when DBOpts.ExpiresColumn is set). The number of
+// migrations newly recorded is returned.
+//
+// Applied migrations are recorded in a table named after the secrets table
+// with a "_migrations" suffix, so Migrate is safe to call each time the store
+// is used, including from several processes at once - a migration recorded by
+// a concurrent caller is treated as applied. Existing tables created by hand
+// are kept, but must have a UNIQUE index on the key column or ErrNoUniqueKey
+// is returned.
+func (s *DB) Migrate() (int, error) {
+	migrationsTable := s.dialect.Quote(s.names.table + "_migrations")
+
+	_, err := s.db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (%s int NOT NULL PRIMARY KEY, %s varchar(255) NOT NULL)",
+		migrationsTable, s.version, s.dialect.Quote("description"),
+	))
+	if err != nil {
+		return 0, err
+	}
+
+	applied, err := s.appliedMigrations(migrationsTable)
+	if err != nil {
+		return 0, err
+	}
+
+	recordSQL := s.rebind(fmt.Sprintf(
+		"INSERT INTO %s (%s, %s) VALUES (?, ?)",
+		migrationsTable, s.version, s.dialect.Quote("description"),
+	))
+
+	n := 0
+	for _, m := range migrations {
+		if !m.enabled(s) || (applied[m.version] && !m.repeat) {
+			continue
+		}
+
+		if err := m.apply(s); err != nil {
+			return n, fmt.Errorf("store: migration %d (%s) failed: %s", m.version, m.description, err)
+		}
+
+		if applied[m.version] {
+			continue
+		}
+
+		_, err := s.db.Exec(recordSQL, m.version, m.description)
+		if s.translate(err) == ErrAlreadyExists {
+			// Recorded by a concurrent Migrate call
+			continue
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	unique, err := s.dialect.HasUniqueIndex(s.db, s.names.table, s.names.key)
+	if err != nil {
+		return n, err
+	}
+
+	if !unique {
+		return n, ErrNoUniqueKey
+	}
+
+	return n, nil
+}
+
+// appliedMigrations returns the set of migration versions recorded in table.
+func (s *DB) appliedMigrations(table string) (map[int]bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("SELECT %s FROM %s", s.version, table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// createSecretsTable creates the secrets table, with a UNIQUE key column.
+func createSecretsTable(s *DB) error {
+	_, err := s.db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (%s, %s varchar(255) NOT NULL UNIQUE, %s %s NOT NULL)",
+		s.table, s.dialect.SerialColumn(s.dialect.Quote("id")), s.key, s.value, s.dialect.BlobType(),
+	))
+
+	return err
+}
+
+// createVersionTable creates the history table used by Versioner.
+func createVersionTable(s *DB) error {
+	_, err := s.db.Exec(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (%s varchar(255) NOT NULL, %s int NOT NULL, %s %s NOT NULL, PRIMARY KEY (%s, %s))",
+		s.history, s.key, s.version, s.value, s.dialect.BlobType(), s.key, s.version,
+	))
+
+	return err
+}
+
+// addColumn adds the unquoted, nullable column to the secrets table, unless it
+// already exists.
+func (s *DB) addColumn(column, columnType string) error {
+	exists, err := s.hasColumn(column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN %s %s NULL",
+		s.table, s.dialect.Quote(column), columnType,
+	))
+
+	if err != nil {
+		// The column may have been added by a concurrent Migrate call
+		if exists, _ := s.hasColumn(column); exists {
+			return nil
+		}
+	}
+
+	return err
+}
+
+// hasColumn reports whether the secrets table has the unquoted column.
+//
+// Selecting the column itself isn't enough - SQLite treats a quoted identifier
+// that isn't a column as a string.
+func (s *DB) hasColumn(column string) (bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", s.table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range columns {
+		if c == column {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
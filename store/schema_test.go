@@ -0,0 +1,272 @@
+package store
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// TestMigrate ensures Migrate creates a working schema for each combination of
+// features, and only applies each migration once.
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		setup []string
+		opts  DBOpts
+		// Expected results.
+		wantApplied int
+		wantErr     error
+	}{
+		{
+			"Empty database",
+			nil,
+			DBOpts{},
+			1,
+			nil,
+		},
+		{
+			"Empty database, all features",
+			nil,
+			DBOpts{VersionTable: "secrets_versions", ExpiresColumn: "expires_at", MetadataColumn: "metadata"},
+			4,
+			nil,
+		},
+		{
+			"Custom names",
+			nil,
+			DBOpts{Table: "keys", Key: "k", Value: "v", ExpiresColumn: "ttl"},
+			2,
+			nil,
+		},
+		{
+			"Existing tables",
+			[]string{tableSQL, versionTableSQL, expiresColumnSQL},
+			DBOpts{VersionTable: "secrets_versions", ExpiresColumn: "expires_at", MetadataColumn: "metadata"},
+			4,
+			nil,
+		},
+		{
+			"Existing table without unique key",
+			[]string{`CREATE TABLE secrets(id INTEGER NOT NULL PRIMARY KEY, name TEXT, data BLOB);`},
+			DBOpts{},
+			1,
+			ErrNoUniqueKey,
+		},
+		{
+			"Existing table with composite unique key",
+			[]string{`CREATE TABLE secrets(id INTEGER NOT NULL PRIMARY KEY, name TEXT, data BLOB, UNIQUE (name, data));`},
+			DBOpts{},
+			1,
+			ErrNoUniqueKey,
+		},
+	}
+
+	for _, tt := range tests {
+		db := newSQLiteDB(t, tt.setup...)
+
+		tt.opts.Dialect = SQLite
+		tt.opts.Migrate = true
+
+		s, err := NewDB(db, &tt.opts)
+		if err != tt.wantErr {
+			t.Errorf("%q. NewDB() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+
+		table := tt.opts.Table
+		if table == "" {
+			table = "secrets"
+		}
+
+		var applied int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table + "_migrations").Scan(&applied); err != nil {
+			t.Errorf("%q. failed to count migrations: %s", tt.name, err)
+		}
+
+		if applied != tt.wantApplied {
+			t.Errorf("%q. applied %d migrations, want %d", tt.name, applied, tt.wantApplied)
+		}
+
+		if tt.wantErr != nil {
+			continue
+		}
+
+		if applied, err := s.Migrate(); applied != 0 || err != nil {
+			t.Errorf("%q. second Migrate() = %d, %v, want 0, nil", tt.name, applied, err)
+		}
+
+		// The schema should support everything enabled
+		data := &encryptor.EncryptedData{Ciphertext: []byte("secret"), Context: map[string]interface{}{}}
+		if err := s.Put("secret", data); err != nil {
+			t.Errorf("%q. Put() error = %v", tt.name, err)
+		}
+
+		if err := s.Put("secret", data); err == nil {
+			t.Errorf("%q. Put() duplicate succeeded", tt.name)
+		}
+
+		if got, err := s.Get("secret"); err != nil || !reflect.DeepEqual(got, data) {
+			t.Errorf("%q. Get() = %v, %v, want %v", tt.name, got, err, data)
+		}
+
+		if tt.opts.VersionTable != "" {
+			if err := s.Update("secret", data); err != nil {
+				t.Errorf("%q. Update() error = %v", tt.name, err)
+			}
+		}
+
+		if tt.opts.ExpiresColumn != "" {
+			if err := s.PutWithTTL("expiring", data, time.Hour); err != nil {
+				t.Errorf("%q. PutWithTTL() error = %v", tt.name, err)
+			}
+		}
+
+		if tt.opts.MetadataColumn != "" {
+			if err := s.PutMetadata("secret", &Metadata{Description: "test"}); err != nil {
+				t.Errorf("%q. PutMetadata() error = %v", tt.name, err)
+			}
+		}
+	}
+}
+
+// TestMigrateNewFeature ensures enabling a feature on an existing schema
+// applies only the migrations it needs.
+func TestMigrateNewFeature(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	s, err := NewDB(db, &DBOpts{Dialect: SQLite, Migrate: true})
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+
+	if applied, err := s.Migrate(); applied != 0 || err != nil {
+		t.Errorf("Migrate() = %d, %v, want 0, nil", applied, err)
+	}
+
+	data := &encryptor.EncryptedData{Ciphertext: []byte("secret"), Context: map[string]interface{}{}}
+	if err := s.Put("secret", data); err != nil {
+		t.Errorf("Put() error = %v", err)
+	}
+
+	s, err = NewDB(db, &DBOpts{Dialect: SQLite, MetadataColumn: "metadata"})
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+
+	if applied, err := s.Migrate(); applied != 1 || err != nil {
+		t.Errorf("Migrate() = %d, %v, want 1, nil", applied, err)
+	}
+
+	// Existing secrets are kept, and have no metadata
+	if got, err := s.Get("secret"); err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("Get() = %v, %v, want %v", got, err, data)
+	}
+
+	if _, err := s.GetMetadata("secret"); err != ErrNoMetadata {
+		t.Errorf("GetMetadata() error = %v, want %v", err, ErrNoMetadata)
+	}
+}
+
+// TestMigrateRenamedColumn ensures changing a feature column or table name adds
+// the new one, even though the migration adding it is already recorded.
+func TestMigrateRenamedColumn(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	if _, err := NewDB(db, &DBOpts{Dialect: SQLite, Migrate: true, ExpiresColumn: "expires_at", VersionTable: "v1"}); err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+
+	s, err := NewDB(db, &DBOpts{Dialect: SQLite, ExpiresColumn: "ttl", VersionTable: "v2"})
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+
+	if applied, err := s.Migrate(); applied != 0 || err != nil {
+		t.Errorf("Migrate() = %d, %v, want 0, nil", applied, err)
+	}
+
+	if exists, err := s.hasColumn("ttl"); !exists || err != nil {
+		t.Errorf("hasColumn() = %v, %v, want true, nil", exists, err)
+	}
+
+	data := &encryptor.EncryptedData{Ciphertext: []byte("secret"), Context: map[string]interface{}{}}
+	if err := s.PutWithTTL("secret", data, time.Hour); err != nil {
+		t.Errorf("PutWithTTL() error = %v", err)
+	}
+
+	if _, err := s.PutVersion("secret", data); err != nil {
+		t.Errorf("PutVersion() error = %v", err)
+	}
+}
+
+// TestMigrateConcurrent ensures a migration recorded by a concurrent Migrate
+// call, after it was found to be missing, is treated as applied.
+func TestMigrateConcurrent(t *testing.T) {
+	orig := migrations
+	defer func() { migrations = orig }()
+
+	// The first apply runs a whole Migrate before creating the table itself
+	concurrent := true
+	migrations = []migration{
+		{
+			version:     1,
+			description: "create secrets table",
+			enabled:     func(s *DB) bool { return true },
+			apply: func(s *DB) error {
+				if concurrent {
+					concurrent = false
+					if applied, err := s.Migrate(); applied != 1 || err != nil {
+						t.Errorf("concurrent Migrate() = %d, %v, want 1, nil", applied, err)
+					}
+				}
+
+				return createSecretsTable(s)
+			},
+		},
+	}
+
+	s, err := NewDB(newSQLiteDB(t, tableSQL), &DBOpts{Dialect: SQLite})
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+
+	if applied, err := s.Migrate(); applied != 0 || err != nil {
+		t.Errorf("Migrate() = %d, %v, want 0, nil", applied, err)
+	}
+}
+
+// TestNewDBMigrate ensures NewDB fails when the schema is missing, unless asked
+// to migrate it.
+func TestNewDBMigrate(t *testing.T) {
+	if _, err := NewDB(newSQLiteDB(t), &DBOpts{Dialect: SQLite}); err == nil {
+		t.Error("NewDB() without tables succeeded")
+	}
+
+	if _, err := NewDB(newSQLiteDB(t), &DBOpts{Dialect: SQLite, Migrate: true}); err != nil {
+		t.Errorf("NewDB() with Migrate error = %v", err)
+	}
+}
+
+// newSQLiteDB returns an in-memory sqlite database after running each of
+// setup.
+func newSQLiteDB(t *testing.T, setup ...string) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to set up sqlite db: %s", err)
+	}
+
+	// Each connection to :memory: is a new database
+	db.SetMaxOpenConns(1)
+
+	for _, q := range setup {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("Failed to create db table: %s", err)
+		}
+	}
+
+	return db
+}
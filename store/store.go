@@ -1,6 +1,11 @@
 package store
 
-import "github.com/domodwyer/cryptic/encryptor"
+import (
+	"context"
+	"time"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
 
 // Putter defines the interface for storing secrets in a back-end store.
 type Putter interface {
@@ -17,9 +22,169 @@ type Deleter interface {
 	Delete(name string) error
 }
 
+// BulkGetter is implemented by stores able to fetch many secrets in a single
+// operation.
+//
+// Secrets that do not exist are omitted from the returned map. Store wrappers
+// return ErrBulkUnsupported if the store they wrap can't fetch secrets in bulk.
+type BulkGetter interface {
+	GetMany(names []string) (map[string]*encryptor.EncryptedData, error)
+}
+
+// Lister is implemented by stores able to enumerate the names of the secrets
+// they hold.
+//
+// Names are returned a page at a time - pass the returned cursor back in
+// ListOpts to fetch the next page. An empty cursor is returned once there are
+// no more pages.
+type Lister interface {
+	List(opts *ListOpts) (names []string, cursor string, err error)
+}
+
+// ListOpts filters and paginates the names returned by a Lister.
+type ListOpts struct {
+	// Prefix restricts the results to names starting with Prefix.
+	Prefix string
+
+	// Limit is the maximum number of names returned per page, defaulting to
+	// 100. Some stores treat Limit as a hint - see each implementation.
+	Limit int
+
+	// Cursor is the cursor returned with the previous page, or empty to start
+	// from the beginning.
+	Cursor string
+}
+
+// Versioner is implemented by stores that keep a history of each secret,
+// allowing a secret to be changed without deleting it first.
+//
+// Versions are numbered from 1, and the latest version is always returned by
+// Get. Secrets stored with Put are version 1 - Put never adds a version, and
+// returns ErrAlreadyExists for an existing secret as with any other store, so
+// new versions are only created explicitly with PutVersion.
+type Versioner interface {
+	// PutVersion stores data as the next version of name, returning the new
+	// version number.
+	PutVersion(name string, data *encryptor.EncryptedData) (int, error)
+
+	// GetVersion fetches a specific version of name.
+	GetVersion(name string, version int) (*encryptor.EncryptedData, error)
+
+	// Versions returns the version numbers held for name in ascending order,
+	// including the latest.
+	Versions(name string) ([]int, error)
+
+	// Prune removes all but the keep most recent versions of name, returning
+	// the number of versions removed. The latest version is never removed.
+	Prune(name string, keep int) (int, error)
+}
+
+// Updater is implemented by stores able to atomically replace a secret.
+//
+// If the secret has a version history (see Versioner), the new value is
+// recorded as the next version.
+type Updater interface {
+	// Update replaces the secret stored under name, returning ErrNotFound if
+	// it does not exist.
+	Update(name string, data *encryptor.EncryptedData) error
+
+	// Upsert stores data under name, replacing any existing secret.
+	Upsert(name string, data *encryptor.EncryptedData) error
+
+	// CompareAndSwap replaces the secret stored under name only if its current
+	// cipher-text matches old, returning ErrConflict if it does not.
+	CompareAndSwap(name string, old, data *encryptor.EncryptedData) error
+}
+
+// VersionSwapper is implemented by versioned stores able to add a version only
+// if the latest version is the one expected.
+type VersionSwapper interface {
+	// CompareAndSwapVersion stores data as the next version of name if the
+	// latest version is version, returning ErrConflict if it is not.
+	CompareAndSwapVersion(name string, version int, data *encryptor.EncryptedData) (int, error)
+}
+
+// Expirer is implemented by stores able to remove secrets automatically once
+// they expire.
+//
+// Once expired, a secret is treated as if it does not exist - Get returns
+// ErrNotFound, and the name can be used again by Put. Replacing a secret (with
+// an Updater or Versioner) removes its expiry.
+type Expirer interface {
+	PutWithTTL(name string, data *encryptor.EncryptedData, ttl time.Duration) error
+}
+
+// Purger is implemented by stores that keep expired secrets around until they
+// are purged. Expired secrets are never returned, purging only frees up the
+// space they use.
+type Purger interface {
+	// Purge removes all expired secrets, returning the number removed.
+	Purge() (int, error)
+}
+
+// Watcher is implemented by stores able to notify clients of changes to the
+// secrets they hold.
+//
+// Watch delivers an event for each secret stored or removed with a name
+// starting with prefix (or every secret if prefix is empty), until ctx is
+// cancelled. The channel is closed once the watch ends - if it failed, the
+// last event has a non-nil Err.
+type Watcher interface {
+	Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error)
+}
+
+// WatchEventType describes the change reported by a WatchEvent.
+type WatchEventType int
+
+const (
+	// WatchPut is reported when a secret is stored or replaced.
+	WatchPut WatchEventType = iota
+
+	// WatchDelete is reported when a secret is deleted or expires.
+	WatchDelete
+)
+
+// WatchEvent reports a change to a secret observed by a Watcher.
+type WatchEvent struct {
+	Type WatchEventType
+	Name string
+
+	// Data is the new secret for WatchPut events, and nil otherwise.
+	Data *encryptor.EncryptedData
+
+	// Err is set on the final event if the watch failed.
+	Err error
+}
+
 // Interface combines the Putter, Getter and Deleter interface
 type Interface interface {
 	Putter
 	Getter
 	Deleter
 }
+
+// ContextPutter defines the interface for storing secrets in a back-end store,
+// abandoning the operation if ctx is cancelled.
+type ContextPutter interface {
+	PutContext(ctx context.Context, name string, data *encryptor.EncryptedData) error
+}
+
+// ContextGetter defines the interface for fetching secrets from the back-end
+// store, abandoning the operation if ctx is cancelled.
+type ContextGetter interface {
+	GetContext(ctx context.Context, name string) (*encryptor.EncryptedData, error)
+}
+
+// ContextDeleter defines the interface for deleting secrets from the back-end
+// store, abandoning the operation if ctx is cancelled.
+type ContextDeleter interface {
+	DeleteContext(ctx context.Context, name string) error
+}
+
+// ContextInterface combines the ContextPutter, ContextGetter and ContextDeleter
+// interface
+type ContextInterface interface {
+	ContextPutter
+	ContextGetter
+	ContextDeleter
+}
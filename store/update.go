@@ -0,0 +1,21 @@
+package store
+
+import (
+	"bytes"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// checkSwap returns nil if the cipher-text of cur matches old, ErrNotFound if
+// there is no current secret, or ErrConflict if it has changed.
+func checkSwap(cur, old *encryptor.EncryptedData) error {
+	if cur == nil {
+		return ErrNotFound
+	}
+
+	if old == nil || !bytes.Equal(cur.Ciphertext, old.Ciphertext) {
+		return ErrConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+// TestUpdater ensures each store only replaces secrets when it should, and
+// records updates to versioned secrets in their history.
+func TestUpdater(t *testing.T) {
+	blinder, _ := NewSIVBlinder([]byte("key"))
+	namespaced, _ := NewNamespaced(NewMemory(), "prod")
+
+	file, cleanupFile := newTestFile(t)
+	defer cleanupFile()
+
+	bolt, cleanupBolt := newTestBolt(t, DefaultBoltBucket)
+	defer cleanupBolt()
+
+	etcd, cleanupEtcd := newTestEtcd(t, "cryptic/")
+	defer cleanupEtcd()
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+	}{
+		{"Memory", NewMemory()},
+		{"DB", newTestDB(t, DBOpts{VersionTable: "secrets_versions"}, versionTableSQL)},
+		{"Blinded", NewBlinded(NewMemory(), blinder)},
+		{"Namespaced", namespaced},
+		{"File", file},
+		{"Bolt", bolt},
+		{"Etcd", etcd},
+	}
+
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+	v3 := &encryptor.EncryptedData{Ciphertext: []byte("v3")}
+
+	for _, tt := range tests {
+		s := tt.store
+		u := s.(Updater)
+
+		if err := u.Update("secret", v1); err != ErrNotFound {
+			t.Errorf("%q. Update() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if err := u.CompareAndSwap("secret", v1, v2); err != ErrNotFound {
+			t.Errorf("%q. CompareAndSwap() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if err := u.Upsert("secret", v1); err != nil {
+			t.Errorf("%q. Upsert() new secret error = %v", tt.name, err)
+			continue
+		}
+
+		if err := u.Upsert("secret", v2); err != nil {
+			t.Errorf("%q. Upsert() existing secret error = %v", tt.name, err)
+		}
+
+		if err := u.CompareAndSwap("secret", v1, v3); err != ErrConflict {
+			t.Errorf("%q. CompareAndSwap() stale error = %v, want %v", tt.name, err, ErrConflict)
+		}
+
+		if err := u.CompareAndSwap("secret", v2, v3); err != nil {
+			t.Errorf("%q. CompareAndSwap() error = %v", tt.name, err)
+		}
+
+		if err := u.Update("secret", v1); err != nil {
+			t.Errorf("%q. Update() error = %v", tt.name, err)
+		}
+
+		if got, err := s.Get("secret"); err != nil || !bytes.Equal(got.Ciphertext, v1.Ciphertext) {
+			t.Errorf("%q. Get() = %v, %v, want %v", tt.name, got, err, v1)
+		}
+
+		if err := s.Delete("secret"); err != nil {
+			t.Errorf("%q. Delete() error = %v", tt.name, err)
+		}
+	}
+}
+
+// TestUpdaterVersioned ensures updates to a versioned secret add versions, and
+// CompareAndSwapVersion rejects stale versions.
+func TestUpdaterVersioned(t *testing.T) {
+	blinder, _ := NewSIVBlinder([]byte("key"))
+	namespaced, _ := NewNamespaced(NewMemory(), "prod")
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+	}{
+		{"Memory", NewMemory()},
+		{"DB", newTestDB(t, DBOpts{VersionTable: "secrets_versions"}, versionTableSQL)},
+		{"Blinded", NewBlinded(NewMemory(), blinder)},
+		{"Namespaced", namespaced},
+	}
+
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+	v3 := &encryptor.EncryptedData{Ciphertext: []byte("v3")}
+
+	for _, tt := range tests {
+		s := tt.store
+		v := s.(Versioner)
+		c := s.(VersionSwapper)
+
+		if _, err := v.PutVersion("secret", v1); err != nil {
+			t.Errorf("%q. PutVersion() error = %v", tt.name, err)
+			continue
+		}
+
+		if err := s.(Updater).Update("secret", v2); err != nil {
+			t.Errorf("%q. Update() error = %v", tt.name, err)
+		}
+
+		if got, err := v.Versions("secret"); err != nil || !reflect.DeepEqual(got, []int{1, 2}) {
+			t.Errorf("%q. Versions() after Update() = %v, %v, want [1 2]", tt.name, got, err)
+		}
+
+		if _, err := c.CompareAndSwapVersion("secret", 1, v3); err != ErrConflict {
+			t.Errorf("%q. CompareAndSwapVersion() stale error = %v, want %v", tt.name, err, ErrConflict)
+		}
+
+		if got, err := c.CompareAndSwapVersion("secret", 2, v3); err != nil || got != 3 {
+			t.Errorf("%q. CompareAndSwapVersion() = %v, %v, want 3", tt.name, got, err)
+		}
+
+		if got, err := s.Get("secret"); err != nil || !bytes.Equal(got.Ciphertext, v3.Ciphertext) {
+			t.Errorf("%q. Get() = %v, %v, want latest %v", tt.name, got, err, v3)
+		}
+
+		if _, err := c.CompareAndSwapVersion("missing", 1, v1); err != ErrNotFound {
+			t.Errorf("%q. CompareAndSwapVersion() missing error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+	}
+}
+
+// TestUpdaterDbNotVersioned ensures updates work without a history table.
+func TestUpdaterDbNotVersioned(t *testing.T) {
+	s := newTestDB(t, DBOpts{})
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+
+	if err := s.Put("secret", v1); err != nil {
+		t.Fatalf("DB.Put() error = %v", err)
+	}
+
+	if err := s.CompareAndSwap("secret", v1, v2); err != nil {
+		t.Errorf("DB.CompareAndSwap() error = %v", err)
+	}
+
+	if got, err := s.Get("secret"); err != nil || !bytes.Equal(got.Ciphertext, v2.Ciphertext) {
+		t.Errorf("DB.Get() = %v, %v, want %v", got, err, v2)
+	}
+
+	// Without a history, Upsert uses the dialect upsert statement
+	for _, name := range []string{"secret", "new"} {
+		if err := s.Upsert(name, v1); err != nil {
+			t.Errorf("DB.Upsert(%q) error = %v", name, err)
+		}
+
+		if got, err := s.Get(name); err != nil || !bytes.Equal(got.Ciphertext, v1.Ciphertext) {
+			t.Errorf("DB.Get(%q) = %v, %v, want %v", name, got, err, v1)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/domodwyer/cryptic/encryptor"
+)
+
+const versionTableSQL = `
+	CREATE TABLE secrets_versions(
+		name TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		data BLOB,
+		PRIMARY KEY (name, version)
+	);
+`
+
+// TestVersioner ensures each store keeps the history of a secret, starting
+// with a secret stored with Put.
+func TestVersioner(t *testing.T) {
+	blinder, _ := NewSIVBlinder([]byte("key"))
+	namespaced, _ := NewNamespaced(NewMemory(), "prod")
+
+	tests := []struct {
+		// Test description.
+		name string
+		// Parameters.
+		store Interface
+	}{
+		{"Memory", NewMemory()},
+		{"DB", newTestDB(t, DBOpts{VersionTable: "secrets_versions"}, versionTableSQL)},
+		{"Blinded", NewBlinded(NewMemory(), blinder)},
+		{"Namespaced", namespaced},
+	}
+
+	v1 := &encryptor.EncryptedData{Ciphertext: []byte("v1")}
+	v2 := &encryptor.EncryptedData{Ciphertext: []byte("v2")}
+	v3 := &encryptor.EncryptedData{Ciphertext: []byte("v3")}
+
+	for _, tt := range tests {
+		s := tt.store
+		v := s.(Versioner)
+
+		if err := s.Put("secret", v1); err != nil {
+			t.Errorf("%q. Put() error = %v", tt.name, err)
+			continue
+		}
+
+		for i, data := range []*encryptor.EncryptedData{v2, v3} {
+			got, err := v.PutVersion("secret", data)
+			if err != nil || got != i+2 {
+				t.Errorf("%q. PutVersion() = %v, %v, want %v", tt.name, got, err, i+2)
+			}
+		}
+
+		if got, err := s.Get("secret"); err != nil || !bytes.Equal(got.Ciphertext, v3.Ciphertext) {
+			t.Errorf("%q. Get() = %v, %v, want latest %v", tt.name, got, err, v3)
+		}
+
+		if got, err := v.GetVersion("secret", 1); err != nil || !bytes.Equal(got.Ciphertext, v1.Ciphertext) {
+			t.Errorf("%q. GetVersion(1) = %v, %v, want %v", tt.name, got, err, v1)
+		}
+
+		if _, err := v.GetVersion("secret", 4); err != ErrNotFound {
+			t.Errorf("%q. GetVersion(4) error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if _, err := v.GetVersion("secret", 0); err != ErrInvalidVersion {
+			t.Errorf("%q. GetVersion(0) error = %v, want %v", tt.name, err, ErrInvalidVersion)
+		}
+
+		if got, err := v.Versions("secret"); err != nil || !reflect.DeepEqual(got, []int{1, 2, 3}) {
+			t.Errorf("%q. Versions() = %v, %v, want [1 2 3]", tt.name, got, err)
+		}
+
+		if err := s.Put("secret", v1); err != ErrAlreadyExists {
+			t.Errorf("%q. Put() error = %v, want %v", tt.name, err, ErrAlreadyExists)
+		}
+
+		if _, err := v.Prune("secret", 0); err != ErrInvalidVersion {
+			t.Errorf("%q. Prune(0) error = %v, want %v", tt.name, err, ErrInvalidVersion)
+		}
+
+		if got, err := v.Prune("secret", 1); err != nil || got != 2 {
+			t.Errorf("%q. Prune(1) = %v, %v, want 2", tt.name, got, err)
+		}
+
+		if got, err := v.Versions("secret"); err != nil || !reflect.DeepEqual(got, []int{3}) {
+			t.Errorf("%q. Versions() after Prune() = %v, %v, want [3]", tt.name, got, err)
+		}
+
+		if _, err := v.GetVersion("secret", 1); err != ErrNotFound {
+			t.Errorf("%q. GetVersion(1) after Prune() error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if got, err := v.PutVersion("secret", v1); err != nil || got != 4 {
+			t.Errorf("%q. PutVersion() after Prune() = %v, %v, want 4", tt.name, got, err)
+		}
+
+		if err := s.Delete("secret"); err != nil {
+			t.Errorf("%q. Delete() error = %v", tt.name, err)
+		}
+
+		if _, err := v.Versions("secret"); err != ErrNotFound {
+			t.Errorf("%q. Versions() after Delete() error = %v, want %v", tt.name, err, ErrNotFound)
+		}
+
+		if got, err := v.PutVersion("new", v1); err != nil || got != 1 {
+			t.Errorf("%q. PutVersion() new secret = %v, %v, want 1", tt.name, got, err)
+		}
+	}
+}
+
+func TestDbVersioningDisabled(t *testing.T) {
+	s := newTestDB(t, DBOpts{})
+
+	if _, err := s.PutVersion("secret", &encryptor.EncryptedData{}); err != ErrVersioningUnsupported {
+		t.Errorf("DB.PutVersion() error = %v, want %v", err, ErrVersioningUnsupported)
+	}
+}
@@ -0,0 +1,35 @@
+package store
+
+import "context"
+
+// mapWatch returns a channel delivering the events received from events, with
+// each name replaced by the result of fn. Events are dropped if fn returns
+// false, except for the final event of a failed watch.
+//
+// The returned channel is closed once events is closed, or ctx is cancelled.
+func mapWatch(ctx context.Context, events <-chan WatchEvent, fn func(name string) (string, bool)) <-chan WatchEvent {
+	out := make(chan WatchEvent)
+
+	go func() {
+		defer close(out)
+
+		for e := range events {
+			if e.Err == nil {
+				name, ok := fn(e.Name)
+				if !ok {
+					continue
+				}
+
+				e.Name = name
+			}
+
+			select {
+			case out <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}